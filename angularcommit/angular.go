@@ -16,6 +16,7 @@ import (
 var (
 	fullAngularHead    = regexp.MustCompile(`^\s*([a-zA-Z]+)\s*\(([^\)]+)\):\s*([^\n]*)`)
 	minimalAngularHead = regexp.MustCompile(`^\s*([a-zA-Z]+):\s*([^\n]*)`)
+	squashBullet       = regexp.MustCompile(`(?m)^\*[ \t]+`)
 	// DefaultOptions for angular commit Analyzer
 	DefaultOptions = &Options{
 		ChoreTypes:   []string{"chore", "docs", "test"},
@@ -29,30 +30,92 @@ var (
 	}
 )
 
+// MergeStrategy selects which commits are analyzed when history contains merges
+type MergeStrategy int
+
+// MergeStrategy values
+const (
+	// AllCommits analyzes every unreleased commit. Merge commits are parsed
+	// like any other commit.
+	AllCommits MergeStrategy = iota
+	// FirstParentCommits analyzes only the commits on the first parent line
+	// of the released commit. The title of a merged pull request is used for
+	// merge commits.
+	FirstParentCommits
+	// MergeCommits analyzes only merge commits, using the title of the merged
+	// pull request found in the merge message.
+	MergeCommits
+)
+
 // Options control how angular commit analyzer behaves
 type Options struct {
 	ChoreTypes            []string
 	FixTypes              []string
 	FeatureTypes          []string
 	BreakingChangeMarkers []string
+	MergeStrategy         MergeStrategy
+	// SquashBullets splits `* type: subject` bullets in the message body
+	// into separate changes, as found in GitHub squash commits. The head
+	// line is a change of its own when it is in angular format.
+	SquashBullets bool
+	// MultipleHeaders emits a change for each line of the message that
+	// starts with a header of a known type, e.g. when `feat:` and `fix:`
+	// lines are bundled in one commit. Types in the body are case
+	// sensitive. Breaking change markers belong to the closest header
+	// above them.
+	MultipleHeaders bool
+	// ReferencePatterns find issue references in the message
+	ReferencePatterns []semrel.ReferencePattern
+}
+
+// InvalidMarkerError is returned by Options.Validate when a breaking change
+// marker is not a valid regular expression
+type InvalidMarkerError struct {
+	// Index of the marker in Options.BreakingChangeMarkers
+	Index  int
+	Marker string
+	Err    error
+}
+
+func (e *InvalidMarkerError) Error() string {
+	return fmt.Sprintf("invalid breaking change marker '%s': %s", e.Marker, e.Err)
+}
+
+// Validate checks options
+func (options *Options) Validate() error {
+	for i, marker := range options.BreakingChangeMarkers {
+		if _, err := compileMarker(marker); err != nil {
+			return &InvalidMarkerError{Index: i, Marker: marker, Err: err}
+		}
+	}
+	return nil
 }
 
 // Analyzer is a semrel.Analyzer instance that parses commits
 // according to angularjs commit conventions
 type Analyzer struct {
 	options *Options
+	// markers are compiled from markersFrom when analyzer is created
+	markers     []*regexp.Regexp
+	markersFrom []string
 }
 
 // NewWithOptions initializes Analyzer with options provided
 func NewWithOptions(options *Options) *Analyzer {
-	return &Analyzer{
+	analyzer := &Analyzer{
 		options: options,
 	}
+	if options == nil {
+		options = DefaultOptions
+	}
+	analyzer.markersFrom = append([]string{}, options.BreakingChangeMarkers...)
+	analyzer.markers = compileMarkers(options.BreakingChangeMarkers)
+	return analyzer
 }
 
 // New initializes Analyzer with DefaultOptions
 func New() *Analyzer {
-	return &Analyzer{}
+	return NewWithOptions(nil)
 }
 
 // Lint checks if message is fomatted according to rules specified in analyzer.
@@ -66,22 +129,22 @@ func (analyzer *Analyzer) Lint(message string) []error {
 	if !ac.isAngular {
 		return []error{errors.New("invalid message head")}
 	}
-	for _, t := range options.ChoreTypes {
-		if ac.CommitType == t {
-			return []error{}
-		}
-	}
-	for _, t := range options.FeatureTypes {
-		if ac.CommitType == t {
-			return []error{}
-		}
+	if options.knownType(ac.CommitType) {
+		return []error{}
 	}
-	for _, t := range options.FixTypes {
-		if ac.CommitType == t {
-			return []error{}
+	return []error{errors.New("invalid type")}
+}
+
+// knownType reports whether commitType is a chore, feature or fix type
+func (options *Options) knownType(commitType string) bool {
+	for _, types := range [][]string{options.ChoreTypes, options.FeatureTypes, options.FixTypes} {
+		for _, t := range types {
+			if commitType == t {
+				return true
+			}
 		}
 	}
-	return []error{errors.New("invalid type")}
+	return false
 }
 
 // Analyze implements semrel.Analyzer interface for angularcommit.Analyzer
@@ -91,18 +154,65 @@ func (analyzer *Analyzer) Analyze(commit *semrel.Commit) ([]semrel.Change, error
 		options = DefaultOptions
 	}
 	changes := []semrel.Change{}
+	switch options.MergeStrategy {
+	case FirstParentCommits:
+		if !commit.IsFirstParent {
+			return changes, nil
+		}
+	case MergeCommits:
+		if !commit.IsMerge {
+			return changes, nil
+		}
+	}
 	message := commit.Msg
-	ac := parseAngularHead(message)
-	ac.BreakingMessage = parseAngularBreakingChange(message, options.BreakingChangeMarkers)
-	ac.commit = *commit
-	ac.options = options
-	ac.Hash = commit.SHA
-	if len(ac.Category()) > 0 {
-		changes = append(changes, ac)
+	if commit.IsMerge && options.MergeStrategy != AllCommits {
+		message = mergeTitle(message)
+	}
+	messages := []string{message}
+	if options.SquashBullets {
+		if bullets := squashBullets(message); len(bullets) > 0 {
+			messages = bullets
+		}
+	}
+	if options.MultipleHeaders {
+		split := []string{}
+		for _, m := range messages {
+			if headers := splitHeaders(m, options); len(headers) > 0 {
+				split = append(split, headers...)
+			} else {
+				split = append(split, m)
+			}
+		}
+		messages = split
+	}
+	for _, m := range messages {
+		ac := parseAngularHead(m)
+		ac.BreakingMessage = parseAngularBreakingChange(m, analyzer.breakingMarkers(options))
+		ac.commit = *commit
+		ac.options = options
+		ac.Hash = commit.SHA
+		ac.References = semrel.FindReferences(m, options.ReferencePatterns)
+		if len(ac.Category()) > 0 {
+			changes = append(changes, ac)
+		}
 	}
 	return changes, nil
 }
 
+// breakingMarkers returns the markers compiled when analyzer was created,
+// or compiles them again if options have changed since
+func (analyzer *Analyzer) breakingMarkers(options *Options) []*regexp.Regexp {
+	if len(analyzer.markersFrom) != len(options.BreakingChangeMarkers) {
+		return compileMarkers(options.BreakingChangeMarkers)
+	}
+	for i, marker := range options.BreakingChangeMarkers {
+		if analyzer.markersFrom[i] != marker {
+			return compileMarkers(options.BreakingChangeMarkers)
+		}
+	}
+	return analyzer.markers
+}
+
 // Change captures commit message analysis
 type Change struct {
 	isAngular       bool
@@ -111,19 +221,14 @@ type Change struct {
 	Subject         string
 	BreakingMessage string
 	Hash            string
+	References      []semrel.Reference
 	commit          semrel.Commit
 	options         *Options
 }
 
 // Category implements semrel.Change interface
 func (commit *Change) Category() string {
-	var categoryMap = map[semrel.BumpLevel]string{
-		semrel.NoBump:    "other",
-		semrel.BumpMajor: "breaking",
-		semrel.BumpMinor: "feature",
-		semrel.BumpPatch: "fix",
-	}
-	return categoryMap[commit.BumpLevel()]
+	return semrel.DefaultCategory(commit.BumpLevel())
 }
 
 // BumpLevel implements semrel.Change interface
@@ -149,6 +254,17 @@ func (commit *Change) PreReleased() bool {
 	return commit.commit.PreReleased
 }
 
+// Describe implements semrel.Describer interface
+func (commit *Change) Describe() semrel.Description {
+	return semrel.Description{
+		Scope:      commit.Scope,
+		Subject:    commit.Subject,
+		Breaking:   commit.BreakingMessage,
+		SHA:        commit.Hash,
+		References: commit.References,
+	}
+}
+
 func parseAngularHead(text string) *Change {
 	t := strings.Replace(text, "\r", "", -1)
 	if match := fullAngularHead.FindStringSubmatch(t); len(match) > 0 {
@@ -172,13 +288,42 @@ func parseAngularHead(text string) *Change {
 	}
 }
 
-func parseAngularBreakingChange(text string, markers []string) string {
+// headType returns the type of angular head line as written, empty if
+// line is not in angular format
+func headType(line string) string {
+	if match := fullAngularHead.FindStringSubmatch(line); len(match) > 0 {
+		return match[1]
+	}
+	if match := minimalAngularHead.FindStringSubmatch(line); len(match) > 0 {
+		return match[1]
+	}
+	return ""
+}
+
+// compileMarkers compiles markers, leaving out the invalid ones
+func compileMarkers(markers []string) []*regexp.Regexp {
+	compiled := []*regexp.Regexp{}
 	for _, marker := range markers {
-		re, err := regexp.Compile(`(?ms)` + marker + `\s+(.*)`)
+		re, err := compileMarker(marker)
 		if err != nil {
-			fmt.Printf("WARNING: unable to compile regular expression for marker '%s'\n", marker)
+			semrel.Logf("WARNING: unable to compile regular expression for marker '%s'", marker)
 			continue
 		}
+		compiled = append(compiled, re)
+	}
+	return compiled
+}
+
+func compileMarker(marker string) (*regexp.Regexp, error) {
+	// report errors in terms of the marker, not the wrapping expression
+	if _, err := regexp.Compile(marker); err != nil {
+		return nil, err
+	}
+	return regexp.Compile(`(?ms)` + marker + `\s+(.*)`)
+}
+
+func parseAngularBreakingChange(text string, markers []*regexp.Regexp) string {
+	for _, re := range markers {
 		if match := re.FindStringSubmatch(text); len(match) > 0 {
 			return strings.Trim(match[1], " \n\t")
 		}
@@ -186,3 +331,73 @@ func parseAngularBreakingChange(text string, markers []string) string {
 	}
 	return ""
 }
+
+// mergeTitle returns the merge message without the generated head line,
+// so that the title of the merged pull request becomes the head.
+// Messages without a generated head are returned as is.
+func mergeTitle(text string) string {
+	t := strings.Replace(text, "\r", "", -1)
+	lines := strings.SplitN(t, "\n", 2)
+	if !strings.HasPrefix(strings.TrimSpace(lines[0]), "Merge ") || len(lines) < 2 {
+		return text
+	}
+	body := strings.TrimLeft(lines[1], " \t\n")
+	if len(body) == 0 {
+		return text
+	}
+	return body
+}
+
+// squashBullets splits the body of a squash commit into messages, one per
+// `* type: subject` bullet. Only bullets with angular head are returned.
+// When there are such bullets, the text before them is returned first, if
+// it has angular head too, e.g. the title of the squashed pull request.
+func squashBullets(text string) []string {
+	t := strings.Replace(text, "\r", "", -1)
+	locs := squashBullet.FindAllStringIndex(t, -1)
+	messages := []string{}
+	for i, loc := range locs {
+		end := len(t)
+		if i+1 < len(locs) {
+			end = locs[i+1][0]
+		}
+		lines := strings.Split(t[loc[1]:end], "\n")
+		for j := range lines {
+			lines[j] = strings.TrimLeft(lines[j], " \t")
+		}
+		m := strings.Join(lines, "\n")
+		if parseAngularHead(m).isAngular {
+			messages = append(messages, m)
+		}
+	}
+	if len(messages) > 0 {
+		if head := strings.TrimSpace(t[:locs[0][0]]); parseAngularHead(head).isAngular {
+			messages = append([]string{head}, messages...)
+		}
+	}
+	return messages
+}
+
+// splitHeaders splits text into messages, one per header. The head line
+// is a header when it is in angular format, other lines only when their
+// type is known with exact case, so that e.g. `Closes: #1` or `Fix: typo`
+// in prose is not taken for a header.
+func splitHeaders(text string, options *Options) []string {
+	t := strings.Replace(text, "\r", "", -1)
+	lines := strings.Split(t, "\n")
+	starts := []int{}
+	for i, line := range lines {
+		if i == 0 && parseAngularHead(line).isAngular || i > 0 && options.knownType(headType(line)) {
+			starts = append(starts, i)
+		}
+	}
+	messages := []string{}
+	for i, start := range starts {
+		end := len(lines)
+		if i+1 < len(starts) {
+			end = starts[i+1]
+		}
+		messages = append(messages, strings.Join(lines[start:end], "\n"))
+	}
+	return messages
+}
@@ -1,8 +1,11 @@
 package angularcommit
 
 import (
+	"fmt"
 	"reflect"
 	"testing"
+
+	"github.com/juranki/go-semrel/semrel"
 )
 
 func TestAngularHead(t *testing.T) {
@@ -43,7 +46,7 @@ func TestAngularHead(t *testing.T) {
 }
 
 func TestBreakingChange(t *testing.T) {
-	markers := []string{"break:", "break"}
+	markers := compileMarkers([]string{"break:", "break"})
 	cases := []struct {
 		msg    string
 		result string
@@ -85,3 +88,200 @@ func TestAnalyzer_Lint(t *testing.T) {
 		})
 	}
 }
+
+func TestAnalyzer_MergeStrategy(t *testing.T) {
+	mergeMsg := "Merge pull request #12 from foo/feat-x\n\nfeat: add x"
+	tests := []struct {
+		name     string
+		strategy MergeStrategy
+		commit   semrel.Commit
+		want     []string
+	}{
+		{"all, plain", AllCommits, semrel.Commit{Msg: "fix: a"}, []string{"fix"}},
+		{"all, merge", AllCommits, semrel.Commit{Msg: mergeMsg, IsMerge: true, IsFirstParent: true}, []string{"other"}},
+		{"first parent, plain", FirstParentCommits, semrel.Commit{Msg: "fix: a", IsFirstParent: true}, []string{"fix"}},
+		{"first parent, branch", FirstParentCommits, semrel.Commit{Msg: "fix: a"}, []string{}},
+		{"first parent, merge", FirstParentCommits, semrel.Commit{Msg: mergeMsg, IsMerge: true, IsFirstParent: true}, []string{"feature"}},
+		{"merges, plain", MergeCommits, semrel.Commit{Msg: "fix: a", IsFirstParent: true}, []string{}},
+		{"merges, merge", MergeCommits, semrel.Commit{Msg: mergeMsg, IsMerge: true}, []string{"feature"}},
+		{"merges, no title", MergeCommits, semrel.Commit{Msg: "Merge branch 'feat/x'", IsMerge: true}, []string{"other"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := *DefaultOptions
+			options.MergeStrategy = tt.strategy
+			changes, err := NewWithOptions(&options).Analyze(&tt.commit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make([]string, len(changes))
+			for i, c := range changes {
+				got[i] = c.Category()
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Analyze() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnalyzer_SquashBullets(t *testing.T) {
+	options := *DefaultOptions
+	options.SquashBullets = true
+	analyzer := NewWithOptions(&options)
+	msg := "Feature x (#12)\n\n* feat(x): add x\n\n* fix: crash\n  when empty\n\n* not conventional\n\n* refactor: y\n\n  BREAKING CHANGE: y is gone\n"
+	changes, err := analyzer.Analyze(&semrel.Commit{Msg: msg})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		category string
+		subject  string
+	}{
+		{"feature", "add x"},
+		{"fix", "crash"},
+		{"breaking", "y"},
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("got %d changes, want %d", len(changes), len(want))
+	}
+	for i, w := range want {
+		c := changes[i].(*Change)
+		if c.Category() != w.category || c.Subject != w.subject {
+			t.Errorf("change %d: got %s '%s', want %s '%s'", i, c.Category(), c.Subject, w.category, w.subject)
+		}
+	}
+	if msg := changes[2].(*Change).BreakingMessage; msg != "y is gone" {
+		t.Errorf("got breaking message '%s', want 'y is gone'", msg)
+	}
+
+	changes, err = analyzer.Analyze(&semrel.Commit{Msg: "feat: plain\n\n* just a list\n"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes[0].Category() != "feature" {
+		t.Errorf("got %+v, want single feature", changes)
+	}
+
+	changes, err = analyzer.Analyze(&semrel.Commit{Msg: "feat: X (#12)\n\n* fix: typo\n"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 2 || changes[0].Category() != "feature" || changes[1].Category() != "fix" {
+		t.Errorf("got %+v, want feature and fix", changes)
+	}
+	if subject := changes[0].(*Change).Subject; subject != "X (#12)" {
+		t.Errorf("got subject '%s'", subject)
+	}
+}
+
+func TestOptions_Validate(t *testing.T) {
+	options := &Options{BreakingChangeMarkers: []string{"break:", "(break"}}
+	err := options.Validate()
+	e, ok := err.(*InvalidMarkerError)
+	if !ok {
+		t.Fatalf("got %v, want *InvalidMarkerError", err)
+	}
+	if e.Index != 1 || e.Marker != "(break" {
+		t.Errorf("got %+v", e)
+	}
+	options = &Options{BreakingChangeMarkers: DefaultOptions.BreakingChangeMarkers}
+	if err := options.Validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+type testLogger []string
+
+func (l *testLogger) Printf(format string, v ...interface{}) {
+	*l = append(*l, fmt.Sprintf(format, v...))
+}
+
+func TestAnalyzer_InvalidMarker(t *testing.T) {
+	logger := &testLogger{}
+	semrel.SetLogger(logger)
+	defer semrel.SetLogger(nil)
+	options := *DefaultOptions
+	options.BreakingChangeMarkers = []string{"(break", "BREAKING:"}
+	analyzer := NewWithOptions(&options)
+	for i := 0; i < 2; i++ {
+		changes, err := analyzer.Analyze(&semrel.Commit{Msg: "fix: x\n\nBREAKING: y"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(changes) != 1 || changes[0].Category() != "breaking" {
+			t.Errorf("got %+v, want breaking change", changes)
+		}
+	}
+	if len(*logger) != 1 {
+		t.Errorf("got %d log messages, want 1: %v", len(*logger), *logger)
+	}
+}
+
+func TestAnalyzer_ChangedMarkers(t *testing.T) {
+	options := *DefaultOptions
+	analyzer := NewWithOptions(&options)
+	options.BreakingChangeMarkers = []string{"BOOM:"}
+	changes, err := analyzer.Analyze(&semrel.Commit{Msg: "fix: x\n\nBOOM: y"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes[0].Category() != "breaking" {
+		t.Errorf("got %+v, want breaking change", changes)
+	}
+}
+
+func TestAnalyzer_MultipleHeaders(t *testing.T) {
+	options := *DefaultOptions
+	options.MultipleHeaders = true
+	analyzer := NewWithOptions(&options)
+	msg := "feat(api): add x\n\nDetails of x.\nfix: crash on empty input\n\nBREAKING CHANGE: empty input is rejected\n" +
+		"Note: not a header\ndocs: update readme\n\nCloses: #1\n"
+	changes, err := analyzer.Analyze(&semrel.Commit{Msg: msg})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		category string
+		scope    string
+		subject  string
+		breaking string
+	}{
+		{"feature", "api", "add x", ""},
+		{"breaking", "", "crash on empty input", "empty input is rejected\nNote: not a header"},
+		{"other", "", "update readme", ""},
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("got %d changes, want %d", len(changes), len(want))
+	}
+	for i, w := range want {
+		c := changes[i].(*Change)
+		if c.Category() != w.category || c.Scope != w.scope || c.Subject != w.subject || c.BreakingMessage != w.breaking {
+			t.Errorf("change %d: got %s '%s' '%s' '%s'", i, c.Category(), c.Scope, c.Subject, c.BreakingMessage)
+		}
+	}
+
+	changes, err = analyzer.Analyze(&semrel.Commit{Msg: "Squashed changes\n\nfix: a\nfeat: b\n"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 2 || changes[0].Category() != "fix" || changes[1].Category() != "feature" {
+		t.Errorf("got %+v", changes)
+	}
+
+	changes, err = analyzer.Analyze(&semrel.Commit{Msg: "feat: a\n\nFix: typo in the docs was annoying\nTest: run with -race\n"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes[0].Category() != "feature" {
+		t.Errorf("got %+v, want single feature", changes)
+	}
+
+	changes, err = NewWithOptions(DefaultOptions).Analyze(&semrel.Commit{Msg: msg})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes[0].Category() != "breaking" {
+		t.Errorf("got %+v", changes)
+	}
+}
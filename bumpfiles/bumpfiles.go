@@ -0,0 +1,185 @@
+// Package bumpfiles updates the version in project manifests
+//
+// Updaters rewrite only the version string and keep the rest of the file,
+// including formatting and comments, untouched.
+package bumpfiles
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/blang/semver"
+	"github.com/pkg/errors"
+)
+
+// Format of a version file
+type Format string
+
+// Supported formats
+const (
+	// PackageJSON is npm package.json, top level "version"
+	PackageJSON Format = "package.json"
+	// HelmChart is Helm Chart.yaml, top level "version"
+	HelmChart Format = "Chart.yaml"
+	// PyProject is pyproject.toml, "version" in [project] or [tool.poetry]
+	PyProject Format = "pyproject.toml"
+	// Cargo is Cargo.toml, "version" in [package] or [workspace.package]
+	Cargo Format = "Cargo.toml"
+	// Maven is pom.xml, /project/version
+	Maven Format = "pom.xml"
+	// GoVersion is a Go source file with `Version` string constant or variable
+	GoVersion Format = "version.go"
+)
+
+// Updater replaces the version in the content of a file
+type Updater interface {
+	Update(content []byte, version string) ([]byte, error)
+}
+
+// UpdaterFunc is an adapter that allows use of ordinary functions as Updaters
+type UpdaterFunc func(content []byte, version string) ([]byte, error)
+
+// Update calls f(content, version)
+func (f UpdaterFunc) Update(content []byte, version string) ([]byte, error) {
+	return f(content, version)
+}
+
+var updaters = map[Format]Updater{
+	PackageJSON: UpdaterFunc(updatePackageJSON),
+	HelmChart:   UpdaterFunc(updateChartYAML),
+	PyProject:   tomlUpdater{tables: [][]string{{"project"}, {"tool", "poetry"}}},
+	Cargo:       tomlUpdater{tables: [][]string{{"package"}, {"workspace", "package"}}},
+	Maven:       UpdaterFunc(updatePOM),
+	GoVersion:   GoUpdater{Name: "Version"},
+}
+
+// UpdaterFor returns the updater of format
+func UpdaterFor(format Format) (Updater, error) {
+	u, ok := updaters[format]
+	if !ok {
+		return nil, errors.Errorf("unknown format '%s'", format)
+	}
+	return u, nil
+}
+
+// DetectFormat selects format by the file name
+func DetectFormat(path string) (Format, error) {
+	base := filepath.Base(path)
+	for format := range updaters {
+		if string(format) == base {
+			return format, nil
+		}
+	}
+	if filepath.Ext(base) == ".go" {
+		return GoVersion, nil
+	}
+	return "", errors.Errorf("unable to detect format of '%s'", path)
+}
+
+// File to update. Format is detected from the file name when empty.
+type File struct {
+	Path   string
+	Format Format
+}
+
+// Result of updating a file
+type Result struct {
+	File    File
+	Changed bool
+	// Diff of the change in unified format
+	Diff string
+}
+
+// Update sets version in files. When dryRun is true, files are not
+// written, the results only report what would change. All files are
+// processed before any of them is written, so a failure leaves the files
+// untouched.
+func Update(files []File, version semver.Version, dryRun bool) ([]Result, error) {
+	v := version.String()
+	results := make([]Result, len(files))
+	contents := make([][]byte, len(files))
+	for i, f := range files {
+		if len(f.Format) == 0 {
+			format, err := DetectFormat(f.Path)
+			if err != nil {
+				return nil, err
+			}
+			f.Format = format
+		}
+		u, err := UpdaterFor(f.Format)
+		if err != nil {
+			return nil, errors.Wrap(err, f.Path)
+		}
+		old, err := ioutil.ReadFile(f.Path)
+		if err != nil {
+			return nil, err
+		}
+		updated, err := u.Update(old, v)
+		if err != nil {
+			return nil, errors.Wrap(err, f.Path)
+		}
+		contents[i] = updated
+		results[i] = Result{
+			File:    f,
+			Changed: string(old) != string(updated),
+			Diff:    diff(f.Path, old, updated),
+		}
+	}
+	if dryRun {
+		return results, nil
+	}
+	for i, r := range results {
+		if !r.Changed {
+			continue
+		}
+		info, err := os.Stat(r.File.Path)
+		if err != nil {
+			return nil, err
+		}
+		if err := ioutil.WriteFile(r.File.Path, contents[i], info.Mode()); err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+// diff returns a unified diff of changed lines. Updaters don't add or
+// remove lines, otherwise whole content is shown as changed.
+func diff(path string, a, b []byte) string {
+	if string(a) == string(b) {
+		return ""
+	}
+	al := strings.SplitAfter(string(a), "\n")
+	bl := strings.SplitAfter(string(b), "\n")
+	out := strings.Builder{}
+	fmt.Fprintf(&out, "--- a/%s\n+++ b/%s\n", filepath.ToSlash(path), filepath.ToSlash(path))
+	line := func(prefix string, l string) {
+		out.WriteString(prefix)
+		out.WriteString(l)
+		if !strings.HasSuffix(l, "\n") {
+			out.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+	if len(al) != len(bl) {
+		fmt.Fprintf(&out, "@@ -1,%d +1,%d @@\n", len(al), len(bl))
+		for _, l := range al {
+			line("-", l)
+		}
+		for _, l := range bl {
+			line("+", l)
+		}
+		return out.String()
+	}
+	for i := range al {
+		if al[i] == bl[i] {
+			continue
+		}
+		fmt.Fprintf(&out, "@@ -%d +%d @@\n", i+1, i+1)
+		line("-", al[i])
+		line("+", bl[i])
+	}
+	return out.String()
+}
@@ -0,0 +1,166 @@
+package bumpfiles
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/blang/semver"
+)
+
+func TestUpdaters(t *testing.T) {
+	tests := []struct {
+		name   string
+		format Format
+		input  string
+		want   string
+	}{
+		{
+			"package.json",
+			PackageJSON,
+			"{\n  \"name\": \"x\",\n  \"dependencies\": {\"version\": \"1.0.0\"},\n  \"version\" :  \"0.1.0\",\n  \"private\": true\n}\n",
+			"{\n  \"name\": \"x\",\n  \"dependencies\": {\"version\": \"1.0.0\"},\n  \"version\" :  \"1.2.3\",\n  \"private\": true\n}\n",
+		},
+		{
+			"Chart.yaml",
+			HelmChart,
+			"apiVersion: v2\nname: x # the chart\nversion: 0.1.0 # bumped\nappVersion: \"0.1.0\"\n",
+			"apiVersion: v2\nname: x # the chart\nversion: 1.2.3 # bumped\nappVersion: \"0.1.0\"\n",
+		},
+		{
+			"Chart.yaml quoted",
+			HelmChart,
+			"name: x\nversion:   '0.1.0'\n",
+			"name: x\nversion:   '1.2.3'\n",
+		},
+		{
+			"pyproject.toml",
+			PyProject,
+			"[build-system]\nrequires = [\"setuptools\"]\n\n[project]\nname = \"x\"\nversion = \"0.1.0\"  # keep\n",
+			"[build-system]\nrequires = [\"setuptools\"]\n\n[project]\nname = \"x\"\nversion = \"1.2.3\"  # keep\n",
+		},
+		{
+			"pyproject.toml poetry",
+			PyProject,
+			"[tool.poetry]\nname = \"x\"\nversion = '0.1.0'\n\n[tool.poetry.dependencies]\npython = \"^3.8\"\n",
+			"[tool.poetry]\nname = \"x\"\nversion = '1.2.3'\n\n[tool.poetry.dependencies]\npython = \"^3.8\"\n",
+		},
+		{
+			"pyproject.toml multi-line string",
+			PyProject,
+			"[project]\nversion = \"\"\"0.1.0\"\"\"\n",
+			"[project]\nversion = \"1.2.3\"\n",
+		},
+		{
+			"Cargo.toml",
+			Cargo,
+			"[package]\nname = \"x\"\nversion = \"0.1.0\"\n\n[dependencies]\nserde = { version = \"1.0\" }\n",
+			"[package]\nname = \"x\"\nversion = \"1.2.3\"\n\n[dependencies]\nserde = { version = \"1.0\" }\n",
+		},
+		{
+			"Cargo.toml workspace",
+			Cargo,
+			"[workspace]\nmembers = [\"x\"]\n\n[workspace.package]\nversion = \"0.1.0\"\n\n[package]\nname = \"x\"\nversion.workspace = true\n",
+			"[workspace]\nmembers = [\"x\"]\n\n[workspace.package]\nversion = \"1.2.3\"\n\n[package]\nname = \"x\"\nversion.workspace = true\n",
+		},
+		{
+			"pom.xml",
+			Maven,
+			"<project>\n  <parent>\n    <version>9</version>\n  </parent>\n  <artifactId>x</artifactId>\n  <version>0.1.0-SNAPSHOT</version>\n  <dependencies><dependency><version>2</version></dependency></dependencies>\n</project>\n",
+			"<project>\n  <parent>\n    <version>9</version>\n  </parent>\n  <artifactId>x</artifactId>\n  <version>1.2.3</version>\n  <dependencies><dependency><version>2</version></dependency></dependencies>\n</project>\n",
+		},
+		{
+			"version.go",
+			GoVersion,
+			"package main\n\n// Version of the app\nconst Version = \"0.1.0\"\n",
+			"package main\n\n// Version of the app\nconst Version = \"1.2.3\"\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := UpdaterFor(tt.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := u.Update([]byte(tt.input), "1.2.3")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdaterErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		format Format
+		input  string
+		want   string
+	}{
+		{"package.json", PackageJSON, `{"name": "x"}`, "version not found"},
+		{"package.json number", PackageJSON, `{"version": 1}`, "version is not a string"},
+		{"Chart.yaml", HelmChart, "name: x\n", "version not found"},
+		{"pyproject.toml dynamic", PyProject, "[project]\ndynamic = [\"version\"]\n", "version not found"},
+		{"pyproject.toml multi-line value", PyProject, "[project]\nversion = \"\"\"\n0.1.0\"\"\"\n", "unable to locate version"},
+		{"Cargo.toml workspace member", Cargo, "[package]\nversion.workspace = true\n", "version not found"},
+		{"Cargo.toml number", Cargo, "[package]\nversion = 1\n", "package.version is not a string"},
+		{"pom.xml stray end", Maven, "</project>", "unexpected end element </project>"},
+		{"pom.xml property", Maven, "<project><version>${revision}</version></project>", "version '${revision}' refers to a property"},
+		{"version.go", GoVersion, "package main\n", "Version is not declared as a constant or variable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, _ := UpdaterFor(tt.format)
+			_, err := u.Update([]byte(tt.input), "1.2.3")
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("got %v, want %s", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bumpfiles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	pkg := filepath.Join(dir, "package.json")
+	chart := filepath.Join(dir, "chart.yml")
+	original := "{\n  \"version\": \"0.1.0\"\n}\n"
+	ioutil.WriteFile(pkg, []byte(original), 0644)
+	ioutil.WriteFile(chart, []byte("version: 1.2.3\n"), 0644)
+	files := []File{{Path: pkg}, {Path: chart, Format: HelmChart}}
+
+	results, err := Update(files, semver.MustParse("1.2.3"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantDiff := "--- a/" + filepath.ToSlash(pkg) + "\n+++ b/" + filepath.ToSlash(pkg) + "\n" +
+		"@@ -2 +2 @@\n-  \"version\": \"0.1.0\"\n+  \"version\": \"1.2.3\"\n"
+	if !results[0].Changed || results[0].Diff != wantDiff {
+		t.Errorf("got %+v, want diff\n%s", results[0], wantDiff)
+	}
+	if results[1].Changed || results[1].Diff != "" {
+		t.Errorf("got %+v, want no change", results[1])
+	}
+	if content, _ := ioutil.ReadFile(pkg); string(content) != original {
+		t.Errorf("dry run modified file: %s", content)
+	}
+
+	if _, err := Update(files, semver.MustParse("1.2.3"), false); err != nil {
+		t.Fatal(err)
+	}
+	if content, _ := ioutil.ReadFile(pkg); string(content) != "{\n  \"version\": \"1.2.3\"\n}\n" {
+		t.Errorf("got %s", content)
+	}
+
+	_, err = Update([]File{{Path: filepath.Join(dir, "build.gradle")}}, semver.MustParse("1.2.3"), true)
+	if err == nil {
+		t.Error("want error for unknown format")
+	}
+}
@@ -0,0 +1,218 @@
+package bumpfiles
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	toml "github.com/pelletier/go-toml"
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v3"
+)
+
+var (
+	tomlVersionLine = regexp.MustCompile(`^(\s*version\s*=\s*)("""[^\n]*?"""|'''[^\n]*?'''|"[^"]*"|'[^']*')`)
+)
+
+// replace returns content with content[start:end] replaced by s
+func replace(content []byte, start, end int, s string) []byte {
+	rv := make([]byte, 0, len(content)-(end-start)+len(s))
+	rv = append(rv, content[:start]...)
+	rv = append(rv, s...)
+	return append(rv, content[end:]...)
+}
+
+// lineOffset returns byte offset of 1-based line and rune column
+func lineOffset(content []byte, line, column int) (int, bool) {
+	offset := 0
+	for l := 1; l < line; l++ {
+		i := bytes.IndexByte(content[offset:], '\n')
+		if i < 0 {
+			return 0, false
+		}
+		offset += i + 1
+	}
+	for c := 1; c < column; c++ {
+		if offset >= len(content) {
+			return 0, false
+		}
+		_, size := utf8.DecodeRune(content[offset:])
+		offset += size
+	}
+	return offset, true
+}
+
+// updatePackageJSON replaces top level "version" of package.json
+func updatePackageJSON(content []byte, version string) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(content))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, errors.New("expected JSON object")
+	}
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		if key != "version" {
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		start := int(dec.InputOffset())
+		value, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := value.(string); !ok {
+			return nil, errors.New("version is not a string")
+		}
+		end := int(dec.InputOffset())
+		start += bytes.IndexByte(content[start:end], '"')
+		quoted, _ := json.Marshal(version)
+		return replace(content, start, end, string(quoted)), nil
+	}
+	return nil, errors.New("version not found")
+}
+
+// updateChartYAML replaces top level "version" of Helm Chart.yaml
+func updateChartYAML(content []byte, version string) ([]byte, error) {
+	doc := yaml.Node{}
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, errors.New("expected YAML mapping")
+	}
+	root := doc.Content[0]
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value != "version" {
+			continue
+		}
+		value := root.Content[i+1]
+		if value.Kind != yaml.ScalarNode {
+			return nil, errors.New("version is not a string")
+		}
+		quote := ""
+		switch value.Style {
+		case 0:
+		case yaml.DoubleQuotedStyle:
+			quote = `"`
+		case yaml.SingleQuotedStyle:
+			quote = `'`
+		default:
+			return nil, errors.New("unsupported style of version")
+		}
+		old := quote + value.Value + quote
+		start, ok := lineOffset(content, value.Line, value.Column)
+		if !ok || !bytes.HasPrefix(content[start:], []byte(old)) {
+			return nil, errors.New("unable to locate version")
+		}
+		return replace(content, start, start+len(old), quote+version+quote), nil
+	}
+	return nil, errors.New("version not found")
+}
+
+// tomlUpdater replaces "version" in the first of tables that has one.
+// Tables that inherit the version, e.g. with version.workspace = true,
+// are skipped.
+type tomlUpdater struct {
+	tables [][]string
+}
+
+func (u tomlUpdater) Update(content []byte, version string) ([]byte, error) {
+	tree, err := toml.LoadBytes(content)
+	if err != nil {
+		return nil, err
+	}
+	for _, table := range u.tables {
+		key := append(append([]string{}, table...), "version")
+		value := tree.GetPath(key)
+		if value == nil {
+			continue
+		}
+		if _, ok := value.(*toml.Tree); ok {
+			// inherited, e.g. version.workspace = true
+			continue
+		}
+		if _, ok := value.(string); !ok {
+			return nil, errors.Errorf("%s is not a string", strings.Join(key, "."))
+		}
+		pos := tree.GetPositionPath(key)
+		start, ok := lineOffset(content, pos.Line, 1)
+		if !ok {
+			return nil, errors.New("unable to locate version")
+		}
+		end := bytes.IndexByte(content[start:], '\n')
+		if end < 0 {
+			end = len(content)
+		} else {
+			end += start
+		}
+		match := tomlVersionLine.FindSubmatchIndex(content[start:end])
+		if match == nil {
+			return nil, errors.New("unable to locate version")
+		}
+		// the value must be all there is on the line, apart from a comment
+		if rest := strings.TrimSpace(string(content[start+match[5] : end])); len(rest) > 0 && rest[0] != '#' {
+			return nil, errors.New("unable to locate version")
+		}
+		quote := string(content[start+match[4]])
+		updated := replace(content, start+match[4], start+match[5], quote+version+quote)
+		check, err := toml.LoadBytes(updated)
+		if err != nil {
+			return nil, errors.Wrap(err, "updated content is not valid")
+		}
+		if check.GetPath(key) != version {
+			return nil, errors.New("unable to update version")
+		}
+		return updated, nil
+	}
+	return nil, errors.New("version not found")
+}
+
+// updatePOM replaces /project/version of Maven pom.xml
+func updatePOM(content []byte, version string) ([]byte, error) {
+	dec := xml.NewDecoder(bytes.NewReader(content))
+	path := []string{}
+	for {
+		start := int(dec.InputOffset())
+		tok, err := dec.RawToken()
+		if err == io.EOF {
+			return nil, errors.New("version not found")
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			path = append(path, t.Name.Local)
+		case xml.EndElement:
+			if len(path) == 2 && path[0] == "project" && path[1] == "version" {
+				return nil, errors.New("version is empty")
+			}
+			if len(path) == 0 {
+				return nil, errors.Errorf("unexpected end element </%s>", t.Name.Local)
+			}
+			path = path[:len(path)-1]
+		case xml.CharData:
+			if len(path) != 2 || path[0] != "project" || path[1] != "version" {
+				continue
+			}
+			old := strings.TrimSpace(string(t))
+			if strings.Contains(old, "${") {
+				return nil, errors.Errorf("version '%s' refers to a property", old)
+			}
+			end := int(dec.InputOffset())
+			start += bytes.Index(content[start:end], []byte(old))
+			var escaped bytes.Buffer
+			xml.EscapeText(&escaped, []byte(version))
+			return replace(content, start, start+len(old), escaped.String()), nil
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package bumpfiles
+
+import (
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"strconv"
+
+	"github.com/juranki/go-semrel/semrel"
+	"github.com/pkg/errors"
+)
+
+// GoUpdater replaces the value of a string constant or variable
+// declared at the top level of Go source file
+type GoUpdater struct {
+	// Name of the constant or variable, "Version" when empty
+	Name string
+}
+
+// Update implements Updater interface. Only the string literal is
+// rewritten, after which the source is formatted with go/format.
+func (u GoUpdater) Update(content []byte, version string) ([]byte, error) {
+	name := u.Name
+	if len(name) == 0 {
+		name = "Version"
+	}
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", content, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	lit, err := findStringLit(f, name)
+	if err != nil {
+		return nil, err
+	}
+	value := strconv.Quote(version)
+	if lit.Value[0] == '`' {
+		value = "`" + version + "`"
+	}
+	start := fset.Position(lit.Pos()).Offset
+	end := fset.Position(lit.End()).Offset
+	return format.Source(replace(content, start, end, value))
+}
+
+func findStringLit(f *ast.File, name string) (*ast.BasicLit, error) {
+	for _, decl := range f.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || (gen.Tok != token.CONST && gen.Tok != token.VAR) {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			vs := spec.(*ast.ValueSpec)
+			for i, ident := range vs.Names {
+				if ident.Name != name {
+					continue
+				}
+				if i >= len(vs.Values) {
+					return nil, errors.Errorf("%s has no value", name)
+				}
+				lit, ok := vs.Values[i].(*ast.BasicLit)
+				if !ok || lit.Kind != token.STRING {
+					return nil, errors.Errorf("%s is not a string literal", name)
+				}
+				return lit, nil
+			}
+		}
+	}
+	return nil, errors.Errorf("%s is not declared as a constant or variable", name)
+}
+
+// UpdateGoFile sets the string constant or variable name in Go source
+// file to release.NextVersion
+func UpdateGoFile(path string, name string, release *semrel.ReleaseData) error {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	updated, err := GoUpdater{Name: name}.Update(content, release.NextVersion.String())
+	if err != nil {
+		return errors.Wrap(err, path)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, updated, info.Mode())
+}
@@ -0,0 +1,84 @@
+package bumpfiles
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/blang/semver"
+	"github.com/juranki/go-semrel/semrel"
+)
+
+const goSource = `package main
+
+import "fmt"
+
+// Build information
+const (
+	Name    = "app"
+	Version = "0.1.0" // set on release
+	Commit  = 42
+)
+
+var Raw, Other = ` + "`0.1.0`" + `, "x"
+
+func main() { fmt.Println(Name, Version) }
+`
+
+func TestGoUpdater(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		wantErr string
+	}{
+		{"Version", `	Version = "1.2.3" // set on release`, ""},
+		{"", `	Version = "1.2.3" // set on release`, ""},
+		{"Raw", "var Raw, Other = `1.2.3`, \"x\"", ""},
+		{"Commit", "", "Commit is not a string literal"},
+		{"main", "", "main is not declared as a constant or variable"},
+		{"Missing", "", "Missing is not declared as a constant or variable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GoUpdater{Name: tt.name}.Update([]byte(goSource), "1.2.3")
+			if len(tt.wantErr) > 0 {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("got %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := goSource
+			for _, line := range []string{`	Version = "0.1.0" // set on release`, "var Raw, Other = `0.1.0`, \"x\""} {
+				if line[:4] == tt.want[:4] {
+					want = strings.Replace(want, line, tt.want, 1)
+				}
+			}
+			if string(got) != want {
+				t.Errorf("got\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestUpdateGoFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bumpfiles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "version.go")
+	ioutil.WriteFile(path, []byte("package x\n\nvar Version   =   \"0.1.0\"\n"), 0644)
+	release := &semrel.ReleaseData{NextVersion: semver.MustParse("0.2.0")}
+	if err := UpdateGoFile(path, "Version", release); err != nil {
+		t.Fatal(err)
+	}
+	content, _ := ioutil.ReadFile(path)
+	if string(content) != "package x\n\nvar Version = \"0.2.0\"\n" {
+		t.Errorf("got %s", content)
+	}
+}
@@ -0,0 +1,262 @@
+// Package changeset analyzes changeset files instead of commit messages
+//
+// Changesets are Markdown files in `.changeset` directory, with front
+// matter that maps packages to bump levels, as in
+// https://github.com/changesets/changesets:
+//
+//	---
+//	"my-module": minor
+//	---
+//
+//	Add support for X
+//
+// The highest level of the front matter is the bump level of the changeset.
+// Pending changesets are read from the HEAD tree, and attributed to
+// the commit that added them. They are deleted when released, see Consume.
+package changeset
+
+import (
+	"io"
+	"path"
+	"strings"
+
+	"github.com/juranki/go-semrel/release"
+	"github.com/juranki/go-semrel/semrel"
+	"github.com/pkg/errors"
+	git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// DefaultDir contains changeset files
+const DefaultDir = ".changeset"
+
+var levels = map[string]semrel.BumpLevel{
+	"major": semrel.BumpMajor,
+	"minor": semrel.BumpMinor,
+	"patch": semrel.BumpPatch,
+	"none":  semrel.NoBump,
+}
+
+// Changeset is a pending change described by a changeset file
+type Changeset struct {
+	// Path of the file in the repository
+	Path  string
+	Level semrel.BumpLevel
+	// Summary is the first line of the note
+	Summary string
+	// Note is the Markdown body of the file
+	Note string
+	// SHA of the commit that added the file
+	SHA    string
+	commit semrel.Commit
+}
+
+// Parse parses the content of changeset file
+func Parse(filePath string, content []byte) (*Changeset, error) {
+	text := strings.Replace(string(content), "\r", "", -1)
+	lines := strings.Split(text, "\n")
+	if len(lines) == 0 || strings.TrimSpace(lines[0]) != "---" {
+		return nil, errors.Errorf("%s: missing front matter", filePath)
+	}
+	c := &Changeset{Path: filePath}
+	end := -1
+	for i := 1; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "---" {
+			end = i
+			break
+		}
+		if len(line) == 0 {
+			continue
+		}
+		sep := strings.LastIndex(line, ":")
+		if sep < 0 {
+			return nil, errors.Errorf("%s:%d: expected 'package: level'", filePath, i+1)
+		}
+		value := strings.Trim(strings.TrimSpace(line[sep+1:]), `"'`)
+		level, ok := levels[value]
+		if !ok {
+			return nil, errors.Errorf("%s:%d: invalid bump level '%s'", filePath, i+1, value)
+		}
+		if level > c.Level {
+			c.Level = level
+		}
+	}
+	if end < 0 {
+		return nil, errors.Errorf("%s: unterminated front matter", filePath)
+	}
+	c.Note = strings.TrimSpace(strings.Join(lines[end+1:], "\n"))
+	c.Summary = strings.TrimSpace(strings.SplitN(c.Note, "\n", 2)[0])
+	return c, nil
+}
+
+// Read returns the changesets in dir of the HEAD tree, DefaultDir when
+// dir is empty. README.md is ignored.
+func Read(r *git.Repository, dir string) ([]*Changeset, error) {
+	if len(dir) == 0 {
+		dir = DefaultDir
+	}
+	head, err := r.Head()
+	if err != nil {
+		return nil, errors.Wrap(err, "get HEAD")
+	}
+	commit, err := r.CommitObject(head.Hash())
+	if err != nil {
+		return nil, err
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, err
+	}
+	changesets := []*Changeset{}
+	dirTree, err := tree.Tree(dir)
+	if err == object.ErrDirectoryNotFound {
+		return changesets, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range dirTree.Entries {
+		if !entry.Mode.IsFile() || path.Ext(entry.Name) != ".md" || strings.EqualFold(entry.Name, "README.md") {
+			continue
+		}
+		filePath := path.Join(dir, entry.Name)
+		file, err := dirTree.File(entry.Name)
+		if err != nil {
+			return nil, err
+		}
+		content, err := file.Contents()
+		if err != nil {
+			return nil, err
+		}
+		c, err := Parse(filePath, []byte(content))
+		if err != nil {
+			return nil, err
+		}
+		if c.SHA, err = addedIn(r, head.Hash().String(), filePath); err != nil {
+			return nil, err
+		}
+		changesets = append(changesets, c)
+	}
+	return changesets, nil
+}
+
+// addedIn returns the SHA of the most recent commit that added filePath,
+// i.e. the one whose parents don't contain it, or head if there is none.
+// Released changesets are deleted, so an earlier file with the same name
+// belongs to an earlier release.
+func addedIn(r *git.Repository, head string, filePath string) (string, error) {
+	commits, err := r.Log(&git.LogOptions{FileName: &filePath})
+	if err != nil {
+		return "", err
+	}
+	defer commits.Close()
+	for {
+		c, err := commits.Next()
+		if err == io.EOF {
+			return head, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		if _, err := c.File(filePath); err != nil {
+			// deleted in c
+			continue
+		}
+		added := true
+		err = c.Parents().ForEach(func(p *object.Commit) error {
+			if _, err := p.File(filePath); err == nil {
+				added = false
+			}
+			return nil
+		})
+		if err != nil {
+			return "", err
+		}
+		if added {
+			return c.Hash.String(), nil
+		}
+	}
+}
+
+// Analyzer is a semrel.Analyzer instance that returns the changesets
+// added by the analyzed commit
+type Analyzer struct {
+	changesets map[string][]*Changeset
+}
+
+// NewAnalyzer initializes Analyzer with changesets returned by Read
+func NewAnalyzer(changesets []*Changeset) *Analyzer {
+	a := &Analyzer{changesets: map[string][]*Changeset{}}
+	for _, c := range changesets {
+		a.changesets[c.SHA] = append(a.changesets[c.SHA], c)
+	}
+	return a
+}
+
+// Analyze implements semrel.Analyzer interface for changeset.Analyzer
+func (analyzer *Analyzer) Analyze(commit *semrel.Commit) ([]semrel.Change, error) {
+	changes := []semrel.Change{}
+	for _, c := range analyzer.changesets[commit.SHA] {
+		change := *c
+		change.commit = *commit
+		changes = append(changes, &change)
+	}
+	return changes, nil
+}
+
+// Category implements semrel.Change interface
+func (c *Changeset) Category() string {
+	return semrel.DefaultCategory(c.Level)
+}
+
+// BumpLevel implements semrel.Change interface
+func (c *Changeset) BumpLevel() semrel.BumpLevel {
+	return c.Level
+}
+
+// PreReleased implements semrel.Change interface
+func (c *Changeset) PreReleased() bool {
+	return c.commit.PreReleased
+}
+
+// Describe implements semrel.Describer interface. Breaking changes are
+// described by the rest of the note.
+func (c *Changeset) Describe() semrel.Description {
+	d := semrel.Description{
+		Subject: c.Summary,
+		SHA:     c.SHA,
+	}
+	if c.Level == semrel.BumpMajor {
+		if parts := strings.SplitN(c.Note, "\n", 2); len(parts) == 2 {
+			d.Breaking = strings.TrimSpace(parts[1])
+		}
+	}
+	return d
+}
+
+// Consume plugin deletes released changeset files from the worktree and
+// the index in Prepare stage, so that they are removed by the release commit
+// of release.GitCommit, which must run after Consume
+type Consume struct {
+	Repository *git.Repository
+	Changesets []*Changeset
+}
+
+// Name implements release.Plugin interface
+func (c *Consume) Name() string { return "changeset" }
+
+// Prepare implements release.Preparer interface
+func (c *Consume) Prepare(ctx *release.Context) error {
+	w, err := c.Repository.Worktree()
+	if err != nil {
+		return err
+	}
+	for _, changeset := range c.Changesets {
+		if _, err := w.Remove(changeset.Path); err != nil {
+			return errors.Wrap(err, changeset.Path)
+		}
+		semrel.Logf("removed %s", changeset.Path)
+	}
+	return nil
+}
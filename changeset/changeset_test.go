@@ -0,0 +1,160 @@
+package changeset
+
+import (
+	"testing"
+	"time"
+
+	"github.com/blang/semver"
+	"github.com/juranki/go-semrel/release"
+	"github.com/juranki/go-semrel/semrel"
+	"gopkg.in/src-d/go-billy.v4/memfs"
+	git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/storage/memory"
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		content string
+		level   semrel.BumpLevel
+		summary string
+		err     string
+	}{
+		{"---\n\"my-module\": minor\n---\n\nAdd X\n\nmore", semrel.BumpMinor, "Add X", ""},
+		{"---\na: patch\n'b': major\n---\nDrop Y", semrel.BumpMajor, "Drop Y", ""},
+		{"---\n---\nDocs", semrel.NoBump, "Docs", ""},
+		{"Add X", 0, "", "x.md: missing front matter"},
+		{"---\na: huge\n---\n", 0, "", "x.md:2: invalid bump level 'huge'"},
+		{"---\na: minor\n", 0, "", "x.md: unterminated front matter"},
+	}
+	for _, c := range cases {
+		got, err := Parse("x.md", []byte(c.content))
+		if len(c.err) > 0 {
+			if err == nil || err.Error() != c.err {
+				t.Errorf("%q: got error %v, want %s", c.content, err, c.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Level != c.level || got.Summary != c.summary {
+			t.Errorf("%q: got %+v", c.content, got)
+		}
+	}
+}
+
+func commitFiles(t *testing.T, w *git.Worktree, msg string, files map[string]string) string {
+	t.Helper()
+	for name, content := range files {
+		f, err := w.Filesystem.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write([]byte(content))
+		f.Close()
+		if _, err := w.Add(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	hash, err := w.Commit(msg, &git.CommitOptions{
+		Author: &object.Signature{Name: "a", Email: "a@b", When: time.Now()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hash.String()
+}
+
+func TestRead(t *testing.T) {
+	r, err := git.Init(memory.NewStorage(), memfs.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := r.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitFiles(t, w, "initial", map[string]string{"main.go": "package main"})
+	changesets, err := Read(r, "")
+	if err != nil || len(changesets) != 0 {
+		t.Fatalf("got %v, %v", changesets, err)
+	}
+
+	first := commitFiles(t, w, "add feature", map[string]string{
+		".changeset/README.md":  "# Changesets",
+		".changeset/feature.md": "---\nm: minor\n---\n\nAdd X",
+	})
+	second := commitFiles(t, w, "fix bug", map[string]string{
+		".changeset/fix.md": "---\nm: patch\n---\n\nFix Y",
+	})
+	commitFiles(t, w, "reword", map[string]string{
+		".changeset/feature.md": "---\nm: minor\n---\n\nAdd X and Z",
+	})
+	changesets, err = Read(r, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changesets) != 2 {
+		t.Fatalf("got %d changesets, want 2", len(changesets))
+	}
+	if c := changesets[0]; c.Path != ".changeset/feature.md" || c.SHA != first || c.Summary != "Add X and Z" {
+		t.Errorf("got %+v", c)
+	}
+	if c := changesets[1]; c.Path != ".changeset/fix.md" || c.SHA != second {
+		t.Errorf("got %+v", c)
+	}
+
+	data := &semrel.VCSData{
+		CurrentVersion: semver.MustParse("1.0.0"),
+		UnreleasedCommits: []semrel.Commit{
+			{Msg: "add feature", SHA: first},
+			{Msg: "fix bug", SHA: second},
+		},
+	}
+	rel, err := semrel.Release(data, NewAnalyzer(changesets))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rel.NextVersion.String() != "1.1.0" || len(rel.Changes["feature"]) != 1 || len(rel.Changes["fix"]) != 1 {
+		t.Errorf("got %+v", rel)
+	}
+
+	consume := &Consume{Repository: r, Changesets: changesets}
+	if err := consume.Prepare(&release.Context{}); err != nil {
+		t.Fatal(err)
+	}
+	status, err := w.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(status) != 2 || status.File(".changeset/fix.md").Staging != git.Deleted {
+		t.Errorf("got status\n%s", status)
+	}
+}
+
+func TestRead_ReAdded(t *testing.T) {
+	r, err := git.Init(memory.NewStorage(), memfs.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := r.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitFiles(t, w, "add", map[string]string{".changeset/x.md": "---\nm: minor\n---\n\nAdd X"})
+	if _, err := w.Remove(".changeset/x.md"); err != nil {
+		t.Fatal(err)
+	}
+	commitFiles(t, w, "release", map[string]string{"VERSION": "1.1.0"})
+	again := commitFiles(t, w, "add again", map[string]string{".changeset/x.md": "---\nm: patch\n---\n\nFix Y"})
+	commitFiles(t, w, "reword", map[string]string{".changeset/x.md": "---\nm: patch\n---\n\nFix Z"})
+
+	changesets, err := Read(r, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changesets) != 1 || changesets[0].SHA != again {
+		t.Errorf("got %+v, want SHA %s", changesets, again)
+	}
+}
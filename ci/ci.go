@@ -0,0 +1,155 @@
+// Package ci detects the CI system that runs the release job
+//
+// CI systems usually check out the commit instead of the branch, so HEAD
+// is detached. Env describes the branch and the commit of the job
+// as reported by the CI system, for branch-dependent logic such as
+// release channels. The outcome of the release is handed to later jobs
+// with WriteFile.
+package ci
+
+import (
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	git "gopkg.in/src-d/go-git.v4"
+)
+
+// Supported CI systems
+const (
+	GitLab    = "gitlab"
+	GitHub    = "github"
+	Jenkins   = "jenkins"
+	Buildkite = "buildkite"
+)
+
+// Env describes the CI job
+type Env struct {
+	// System is one of GitLab, GitHub, Jenkins and Buildkite
+	System string
+	// Branch that is built. For pull and merge requests this is the source
+	// branch. Empty for tag builds.
+	Branch string
+	// PullRequest is true when the job builds a pull or merge request
+	PullRequest bool
+	// TargetBranch of the pull or merge request
+	TargetBranch string
+	// SHA of the commit that is built
+	SHA string
+}
+
+// Detect returns the environment of the CI job, nil when not running in CI
+func Detect() *Env {
+	return DetectFrom(os.Getenv)
+}
+
+// DetectFrom is Detect with environment variables from getenv
+func DetectFrom(getenv func(string) string) *Env {
+	switch {
+	case getenv("GITLAB_CI") == "true":
+		return gitlab(getenv)
+	case getenv("GITHUB_ACTIONS") == "true":
+		return github(getenv)
+	case getenv("BUILDKITE") == "true":
+		return buildkite(getenv)
+	case len(getenv("JENKINS_URL")) > 0:
+		return jenkins(getenv)
+	}
+	return nil
+}
+
+func gitlab(getenv func(string) string) *Env {
+	e := &Env{
+		System: GitLab,
+		Branch: getenv("CI_COMMIT_BRANCH"),
+		SHA:    getenv("CI_COMMIT_SHA"),
+	}
+	if len(getenv("CI_MERGE_REQUEST_IID")) > 0 {
+		e.PullRequest = true
+		e.Branch = getenv("CI_MERGE_REQUEST_SOURCE_BRANCH_NAME")
+		e.TargetBranch = getenv("CI_MERGE_REQUEST_TARGET_BRANCH_NAME")
+	} else if len(e.Branch) == 0 && len(getenv("CI_COMMIT_TAG")) == 0 {
+		// GitLab before 12.6 has only CI_COMMIT_REF_NAME
+		e.Branch = getenv("CI_COMMIT_REF_NAME")
+	}
+	return e
+}
+
+func github(getenv func(string) string) *Env {
+	e := &Env{
+		System: GitHub,
+		SHA:    getenv("GITHUB_SHA"),
+	}
+	switch getenv("GITHUB_EVENT_NAME") {
+	case "pull_request", "pull_request_target":
+		e.PullRequest = true
+		e.Branch = getenv("GITHUB_HEAD_REF")
+		e.TargetBranch = getenv("GITHUB_BASE_REF")
+	default:
+		ref := getenv("GITHUB_REF")
+		if strings.HasPrefix(ref, "refs/heads/") {
+			e.Branch = strings.TrimPrefix(ref, "refs/heads/")
+		}
+	}
+	return e
+}
+
+func jenkins(getenv func(string) string) *Env {
+	e := &Env{
+		System: Jenkins,
+		SHA:    getenv("GIT_COMMIT"),
+	}
+	if len(getenv("CHANGE_ID")) > 0 {
+		e.PullRequest = true
+		e.Branch = getenv("CHANGE_BRANCH")
+		e.TargetBranch = getenv("CHANGE_TARGET")
+		return e
+	}
+	// BRANCH_NAME is set by multibranch pipelines, GIT_BRANCH by git plugin
+	e.Branch = getenv("BRANCH_NAME")
+	if len(e.Branch) == 0 {
+		remote := getenv("GIT_REMOTE")
+		if len(remote) == 0 {
+			remote = "origin"
+		}
+		// the remote is part of the branch, e.g. origin/master
+		e.Branch = getenv("GIT_BRANCH")
+		for _, prefix := range []string{"refs/heads/", "refs/remotes/" + remote + "/", remote + "/"} {
+			e.Branch = strings.TrimPrefix(e.Branch, prefix)
+		}
+	}
+	return e
+}
+
+func buildkite(getenv func(string) string) *Env {
+	e := &Env{
+		System: Buildkite,
+		Branch: getenv("BUILDKITE_BRANCH"),
+		SHA:    getenv("BUILDKITE_COMMIT"),
+	}
+	if pr := getenv("BUILDKITE_PULL_REQUEST"); len(pr) > 0 && pr != "false" {
+		e.PullRequest = true
+		e.TargetBranch = getenv("BUILDKITE_PULL_REQUEST_BASE_BRANCH")
+	}
+	if len(getenv("BUILDKITE_TAG")) > 0 && getenv("BUILDKITE_TAG") == e.Branch {
+		// tag builds report the tag as branch
+		e.Branch = ""
+	}
+	return e
+}
+
+// Branch returns the branch that is checked out in r. When HEAD is
+// detached, the branch of env is returned instead. Env may be nil.
+func Branch(r *git.Repository, env *Env) (string, error) {
+	head, err := r.Head()
+	if err != nil {
+		return "", errors.Wrap(err, "get HEAD")
+	}
+	if head.Name().IsBranch() {
+		return head.Name().Short(), nil
+	}
+	if env != nil && (len(env.SHA) == 0 || env.SHA == head.Hash().String()) {
+		return env.Branch, nil
+	}
+	return "", nil
+}
@@ -0,0 +1,220 @@
+package ci
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/blang/semver"
+	"github.com/juranki/go-semrel/semrel"
+	"gopkg.in/src-d/go-billy.v4/memfs"
+	git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/storage/memory"
+)
+
+func TestDetectFrom(t *testing.T) {
+	cases := []struct {
+		name string
+		env  map[string]string
+		want *Env
+	}{
+		{"none", map[string]string{"CI": "true"}, nil},
+		{"gitlab branch", map[string]string{
+			"GITLAB_CI": "true", "CI_COMMIT_BRANCH": "main", "CI_COMMIT_SHA": "abc",
+		}, &Env{System: GitLab, Branch: "main", SHA: "abc"}},
+		{"gitlab merge request", map[string]string{
+			"GITLAB_CI": "true", "CI_MERGE_REQUEST_IID": "7", "CI_COMMIT_SHA": "abc",
+			"CI_MERGE_REQUEST_SOURCE_BRANCH_NAME": "feature", "CI_MERGE_REQUEST_TARGET_BRANCH_NAME": "main",
+		}, &Env{System: GitLab, Branch: "feature", PullRequest: true, TargetBranch: "main", SHA: "abc"}},
+		{"gitlab tag", map[string]string{
+			"GITLAB_CI": "true", "CI_COMMIT_TAG": "v1.0.0", "CI_COMMIT_REF_NAME": "v1.0.0",
+		}, &Env{System: GitLab}},
+		{"github push", map[string]string{
+			"GITHUB_ACTIONS": "true", "GITHUB_EVENT_NAME": "push", "GITHUB_REF": "refs/heads/release/1.x", "GITHUB_SHA": "abc",
+		}, &Env{System: GitHub, Branch: "release/1.x", SHA: "abc"}},
+		{"github tag", map[string]string{
+			"GITHUB_ACTIONS": "true", "GITHUB_EVENT_NAME": "push", "GITHUB_REF": "refs/tags/v1.0.0",
+		}, &Env{System: GitHub}},
+		{"github pull request", map[string]string{
+			"GITHUB_ACTIONS": "true", "GITHUB_EVENT_NAME": "pull_request", "GITHUB_REF": "refs/pull/3/merge",
+			"GITHUB_HEAD_REF": "feature", "GITHUB_BASE_REF": "main", "GITHUB_SHA": "abc",
+		}, &Env{System: GitHub, Branch: "feature", PullRequest: true, TargetBranch: "main", SHA: "abc"}},
+		{"jenkins multibranch", map[string]string{
+			"JENKINS_URL": "http://ci", "BRANCH_NAME": "main", "GIT_COMMIT": "abc",
+		}, &Env{System: Jenkins, Branch: "main", SHA: "abc"}},
+		{"jenkins git plugin", map[string]string{
+			"JENKINS_URL": "http://ci", "GIT_BRANCH": "origin/release/1.x",
+		}, &Env{System: Jenkins, Branch: "release/1.x"}},
+		{"jenkins git plugin without remote", map[string]string{
+			"JENKINS_URL": "http://ci", "GIT_BRANCH": "feature/x",
+		}, &Env{System: Jenkins, Branch: "feature/x"}},
+		{"jenkins git plugin with remote", map[string]string{
+			"JENKINS_URL": "http://ci", "GIT_BRANCH": "upstream/feature/x", "GIT_REMOTE": "upstream",
+		}, &Env{System: Jenkins, Branch: "feature/x"}},
+		{"jenkins change request", map[string]string{
+			"JENKINS_URL": "http://ci", "BRANCH_NAME": "PR-3", "CHANGE_ID": "3", "CHANGE_BRANCH": "feature", "CHANGE_TARGET": "main",
+		}, &Env{System: Jenkins, Branch: "feature", PullRequest: true, TargetBranch: "main"}},
+		{"buildkite branch", map[string]string{
+			"BUILDKITE": "true", "BUILDKITE_BRANCH": "main", "BUILDKITE_COMMIT": "abc", "BUILDKITE_PULL_REQUEST": "false",
+		}, &Env{System: Buildkite, Branch: "main", SHA: "abc"}},
+		{"buildkite pull request", map[string]string{
+			"BUILDKITE": "true", "BUILDKITE_BRANCH": "feature", "BUILDKITE_PULL_REQUEST": "3", "BUILDKITE_PULL_REQUEST_BASE_BRANCH": "main",
+		}, &Env{System: Buildkite, Branch: "feature", PullRequest: true, TargetBranch: "main"}},
+		{"buildkite tag", map[string]string{
+			"BUILDKITE": "true", "BUILDKITE_BRANCH": "v1.0.0", "BUILDKITE_TAG": "v1.0.0",
+		}, &Env{System: Buildkite}},
+	}
+	for _, c := range cases {
+		got := DetectFrom(func(key string) string { return c.env[key] })
+		if (got == nil) != (c.want == nil) || got != nil && *got != *c.want {
+			t.Errorf("%s: got %+v, want %+v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestBranch(t *testing.T) {
+	r, err := git.Init(memory.NewStorage(), memfs.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := r.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash, err := w.Commit("initial", &git.CommitOptions{
+		Author: &object.Signature{Name: "a", Email: "a@b", When: time.Now()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	env := &Env{System: GitLab, Branch: "main", SHA: hash.String()}
+	if branch, err := Branch(r, env); err != nil || branch != "master" {
+		t.Errorf("got %s, %v", branch, err)
+	}
+	if err := w.Checkout(&git.CheckoutOptions{Hash: hash}); err != nil {
+		t.Fatal(err)
+	}
+	if branch, err := Branch(r, env); err != nil || branch != "main" {
+		t.Errorf("got %s, %v", branch, err)
+	}
+	if branch, err := Branch(r, &Env{Branch: "main", SHA: "other"}); err != nil || branch != "" {
+		t.Errorf("got %s, %v", branch, err)
+	}
+	if branch, err := Branch(r, nil); err != nil || branch != "" {
+		t.Errorf("got %s, %v", branch, err)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	data := &semrel.ReleaseData{
+		CurrentVersion: semver.MustParse("1.0.0"),
+		NextVersion:    semver.MustParse("1.1.0"),
+		BumpLevel:      semrel.BumpMinor,
+	}
+	vars := ReleaseVars(data, "v1.1.0", "## v1.1.0\n\n- \"quoted\" \\ EOF")
+	cases := []struct {
+		format string
+		want   string
+	}{
+		{GitHubOutput, "NEXT_VERSION=1.1.0\nNEXT_TAG=v1.1.0\nBUMP_LEVEL=minor\nRELEASE_NEEDED=true\n" +
+			"RELEASE_NOTES<<EOF_0\n## v1.1.0\n\n- \"quoted\" \\ EOF\nEOF_0\n"},
+		{Dotenv, "NEXT_VERSION=1.1.0\nNEXT_TAG=v1.1.0\nBUMP_LEVEL=minor\nRELEASE_NEEDED=true\n"},
+		{JSON, `{
+  "BUMP_LEVEL": "minor",
+  "NEXT_TAG": "v1.1.0",
+  "NEXT_VERSION": "1.1.0",
+  "RELEASE_NEEDED": "true",
+  "RELEASE_NOTES": "## v1.1.0\n\n- \"quoted\" \\ EOF"
+}
+`},
+	}
+	for _, c := range cases {
+		b := &bytes.Buffer{}
+		if err := Write(b, c.format, vars); err != nil {
+			t.Fatal(err)
+		}
+		if b.String() != c.want {
+			t.Errorf("%s: got\n%s\nwant\n%s", c.format, b, c.want)
+		}
+	}
+	if err := Write(&bytes.Buffer{}, "xml", vars); err == nil {
+		t.Error("expected error for unknown format")
+	}
+	b := &bytes.Buffer{}
+	if err := Write(b, Dotenv, []Var{{"A", "1"}, {"B", "1\n2"}}); err == nil || err.Error() != "B: dotenv does not support multi-line values" || b.Len() > 0 {
+		t.Errorf("expected error for multi-line value, got %v, %q", err, b)
+	}
+	if err := Write(b, Dotenv, []Var{{"A", `"quoted" \n`}}); err != nil || b.String() != `A="quoted" \n`+"\n" {
+		t.Errorf("got %q, %v", b, err)
+	}
+
+	none := ReleaseVars(nil, "", "")
+	if none[0].Value != "" || none[2].Value != "none" || none[3].Value != "false" {
+		t.Errorf("got %v", none)
+	}
+}
+
+func TestNotesFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ci")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "notes.md")
+	vars, err := NotesFile(path, ReleaseVars(nil, "v1.0.0", "## v1.0.0\n\n- fix\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &bytes.Buffer{}
+	if err := Write(b, Dotenv, vars); err != nil {
+		t.Fatal(err)
+	}
+	want := "NEXT_VERSION=\nNEXT_TAG=v1.0.0\nBUMP_LEVEL=none\nRELEASE_NEEDED=false\nRELEASE_NOTES_FILE=" + path + "\n"
+	if b.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", b, want)
+	}
+	notes, err := ioutil.ReadFile(path)
+	if err != nil || string(notes) != "## v1.0.0\n\n- fix\n" {
+		t.Errorf("got %q, %v", notes, err)
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ci")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	vars := []Var{{"A", "1"}}
+	for _, format := range []string{GitHubOutput, Dotenv} {
+		path := filepath.Join(dir, format)
+		for i := 0; i < 2; i++ {
+			if err := WriteFile(path, format, vars); err != nil {
+				t.Fatal(err)
+			}
+		}
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := "A=1\n"
+		if format == GitHubOutput {
+			want = "A=1\nA=1\n"
+		}
+		if string(b) != want {
+			t.Errorf("%s: got %q", format, b)
+		}
+	}
+
+	path := filepath.Join(dir, Dotenv)
+	if err := WriteFile(path, Dotenv, []Var{{"B", "1"}, {"C", "\r"}}); err == nil {
+		t.Error("expected error for multi-line value")
+	}
+	if b, err := ioutil.ReadFile(path); err != nil || string(b) != "A=1\n" {
+		t.Errorf("got %q, %v, want unmodified file", b, err)
+	}
+}
@@ -0,0 +1,165 @@
+package ci
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/juranki/go-semrel/semrel"
+	"github.com/pkg/errors"
+)
+
+// Output formats
+const (
+	// GitHubOutput is the format of $GITHUB_OUTPUT file of GitHub Actions
+	GitHubOutput = "github"
+	// Dotenv is the format of GitLab dotenv report artifacts. Dotenv has
+	// no multi-line values, RELEASE_NOTES is left out. See NotesFile.
+	Dotenv = "dotenv"
+	// JSON object that maps names to values
+	JSON = "json"
+)
+
+const releaseNotes = "RELEASE_NOTES"
+
+var bumpNames = map[semrel.BumpLevel]string{
+	semrel.NoBump:    "none",
+	semrel.BumpPatch: "patch",
+	semrel.BumpMinor: "minor",
+	semrel.BumpMajor: "major",
+}
+
+// Var is a named output of the release job
+type Var struct {
+	Name  string
+	Value string
+}
+
+// ReleaseVars returns the outputs of a release run: NEXT_VERSION,
+// NEXT_TAG, BUMP_LEVEL, RELEASE_NEEDED and RELEASE_NOTES. With release.Run
+// they are ctx.Release, ctx.Tag() and ctx.Notes. Data may be nil.
+// RELEASE_NOTES is not written in Dotenv format.
+func ReleaseVars(data *semrel.ReleaseData, tag string, notes string) []Var {
+	next := ""
+	bump := bumpNames[semrel.NoBump]
+	if data != nil {
+		next = data.NextVersion.String()
+		bump = bumpNames[data.BumpLevel]
+	}
+	return []Var{
+		{"NEXT_VERSION", next},
+		{"NEXT_TAG", tag},
+		{"BUMP_LEVEL", bump},
+		{"RELEASE_NEEDED", fmt.Sprint(data != nil && data.BumpLevel != semrel.NoBump)},
+		{releaseNotes, notes},
+	}
+}
+
+// Write writes vars to w in format
+func Write(w io.Writer, format string, vars []Var) error {
+	switch format {
+	case GitHubOutput:
+		return writeGitHubOutput(w, vars)
+	case Dotenv:
+		return writeDotenv(w, vars)
+	case JSON:
+		values := map[string]string{}
+		for _, v := range vars {
+			values[v.Name] = v.Value
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(values)
+	}
+	return errors.Errorf("unknown output format '%s'", format)
+}
+
+// WriteFile writes vars to file in format. GitHubOutput is appended to
+// the file, as the file is shared by the steps of the job, other formats
+// replace its content. The file is not modified when vars can't be
+// written in format.
+func WriteFile(path string, format string, vars []Var) error {
+	b := &bytes.Buffer{}
+	if err := Write(b, format, vars); err != nil {
+		return errors.Wrap(err, path)
+	}
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if format == GitHubOutput {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	}
+	f, err := os.OpenFile(path, flags, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(b.Bytes()); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// NotesFile writes RELEASE_NOTES of vars to path and returns vars with
+// RELEASE_NOTES replaced by RELEASE_NOTES_FILE, the path of the file.
+// Use it for Dotenv, which has no multi-line values.
+func NotesFile(path string, vars []Var) ([]Var, error) {
+	result := []Var{}
+	for _, v := range vars {
+		if v.Name != releaseNotes {
+			result = append(result, v)
+			continue
+		}
+		if err := ioutil.WriteFile(path, []byte(v.Value), 0644); err != nil {
+			return nil, err
+		}
+		result = append(result, Var{"RELEASE_NOTES_FILE", path})
+	}
+	return result, nil
+}
+
+// writeGitHubOutput writes multi-line values with a heredoc style delimiter
+// that does not occur in the value
+func writeGitHubOutput(w io.Writer, vars []Var) error {
+	for _, v := range vars {
+		if !strings.ContainsAny(v.Value, "\r\n") {
+			if _, err := fmt.Fprintf(w, "%s=%s\n", v.Name, v.Value); err != nil {
+				return err
+			}
+			continue
+		}
+		delimiter := "EOF"
+		for i := 0; strings.Contains(v.Value, delimiter); i++ {
+			delimiter = fmt.Sprintf("EOF_%d", i)
+		}
+		if _, err := fmt.Fprintf(w, "%s<<%s\n%s\n%s\n", v.Name, delimiter, v.Value, delimiter); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeDotenv writes one variable per line as is. GitLab does not decode
+// quotes or escapes in dotenv reports and has no multi-line values, so
+// RELEASE_NOTES is left out and other values with line breaks are an
+// error. Nothing is written when there is an error.
+func writeDotenv(w io.Writer, vars []Var) error {
+	lines := []Var{}
+	for _, v := range vars {
+		if v.Name == releaseNotes {
+			continue
+		}
+		if strings.ContainsAny(v.Value, "\r\n") {
+			return errors.Errorf("%s: dotenv does not support multi-line values", v.Name)
+		}
+		lines = append(lines, v)
+	}
+	for _, v := range lines {
+		if _, err := fmt.Fprintf(w, "%s=%s\n", v.Name, v.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
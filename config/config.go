@@ -0,0 +1,382 @@
+// Package config loads repository configuration for go-semrel
+//
+// Configuration is read from `.semrel.yaml`, `.semrel.json` or `.semrel.toml`
+// in the root of the repository. All keys are optional, missing keys keep
+// their default values.
+//
+//	tag_prefix: v
+//	analyzer:
+//	  type: angular
+//	  feature_types: [feat]
+//	  merge_strategy: first-parent
+//	branches:
+//	  - name: master
+//	  - name: next
+//	    channel: beta
+//	skip:
+//	  - message: '\[skip release\]'
+//	references:
+//	  - pattern: '#(\d+)'
+//	    url: https://github.com/owner/repo/issues/$1
+//
+// Instead of angular conventions, commits can be analyzed with rules:
+//
+//	analyzer:
+//	  type: rules
+//	  rules:
+//	    - name: hotfix
+//	      pattern: '^HOTFIX\s+(?P<subject>.*)'
+//	      bump: patch
+package config
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/juranki/go-semrel/angularcommit"
+	"github.com/juranki/go-semrel/rulecommit"
+	"github.com/juranki/go-semrel/semrel"
+)
+
+var (
+	channelRe = regexp.MustCompile(`^[0-9A-Za-z-]+(\.[0-9A-Za-z-]+)*$`)
+	shaRe     = regexp.MustCompile(`^[0-9a-f]{4,40}$`)
+	// release tag without prefix, see https://semver.org/
+	versionPattern = `v?(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)` +
+		`(-[0-9A-Za-z-]+(\.[0-9A-Za-z-]+)*)?(\+[0-9A-Za-z-]+(\.[0-9A-Za-z-]+)*)?`
+	bumpLevels = map[string]semrel.BumpLevel{
+		"major": semrel.BumpMajor,
+		"minor": semrel.BumpMinor,
+		"patch": semrel.BumpPatch,
+		"none":  semrel.NoBump,
+	}
+	mergeStrategies = map[string]angularcommit.MergeStrategy{
+		"":             angularcommit.AllCommits,
+		"all":          angularcommit.AllCommits,
+		"first-parent": angularcommit.FirstParentCommits,
+		"merges":       angularcommit.MergeCommits,
+	}
+)
+
+// Config contains repository configuration
+type Config struct {
+	// TagPrefix precedes the version in release tags
+	TagPrefix string     `config:"tag_prefix"`
+	Analyzer  Analyzer   `config:"analyzer"`
+	Branches  []Branch   `config:"branches"`
+	Skip      []SkipRule `config:"skip"`
+	// References link issues mentioned in commit messages
+	References []Reference `config:"references"`
+
+	file  string
+	lines map[string]int
+	skip  []*regexp.Regexp
+}
+
+// Analyzer configures commit analyzer
+type Analyzer struct {
+	// Type of the analyzer, "angular" or "rules"
+	Type                  string   `config:"type"`
+	ChoreTypes            []string `config:"chore_types"`
+	FixTypes              []string `config:"fix_types"`
+	FeatureTypes          []string `config:"feature_types"`
+	BreakingChangeMarkers []string `config:"breaking_change_markers"`
+	// MergeStrategy is one of "all", "first-parent" or "merges"
+	MergeStrategy string `config:"merge_strategy"`
+	SquashBullets bool   `config:"squash_bullets"`
+	// MultipleHeaders emits a change per conventional header in the message
+	MultipleHeaders bool `config:"multiple_headers"`
+	// Rules of "rules" analyzer, see rulecommit.Rule
+	Rules []Rule `config:"rules"`
+}
+
+// Rule of "rules" analyzer
+type Rule struct {
+	Name    string `config:"name"`
+	Pattern string `config:"pattern"`
+	// Bump is one of "major", "minor", "patch" or "none"
+	Bump     string `config:"bump"`
+	Category string `config:"category"`
+}
+
+// Branch maps branches to release channels
+type Branch struct {
+	// Name of the branch, may contain shell patterns
+	Name string `config:"name"`
+	// Channel is the pre-release identifier of releases from the branch.
+	// Empty channel means stable releases.
+	Channel string `config:"channel"`
+}
+
+// SkipRule excludes commits from analysis
+type SkipRule struct {
+	// Message is a regular expression matched against commit message
+	Message string `config:"message"`
+	// SHA is a prefix of commit hash
+	SHA string `config:"sha"`
+}
+
+// Reference is a pattern of issue references, see semrel.ReferencePattern
+type Reference struct {
+	// Pattern is a regular expression that matches the reference
+	Pattern string `config:"pattern"`
+	// URL template, submatches are expanded as $1 or ${name}
+	URL string `config:"url"`
+}
+
+// Error describes a problem in configuration
+type Error struct {
+	File string
+	Key  string
+	Line int
+	Msg  string
+}
+
+func (e *Error) Error() string {
+	b := strings.Builder{}
+	if len(e.File) > 0 {
+		b.WriteString(e.File)
+		if e.Line > 0 {
+			fmt.Fprintf(&b, ":%d", e.Line)
+		}
+		b.WriteString(": ")
+	}
+	if len(e.Key) > 0 {
+		b.WriteString(e.Key)
+		b.WriteString(": ")
+	}
+	b.WriteString(e.Msg)
+	return b.String()
+}
+
+// Default returns configuration that matches the defaults of the library
+func Default() *Config {
+	options := angularcommit.DefaultOptions
+	return &Config{
+		Analyzer: Analyzer{
+			Type:                  "angular",
+			ChoreTypes:            append([]string{}, options.ChoreTypes...),
+			FixTypes:              append([]string{}, options.FixTypes...),
+			FeatureTypes:          append([]string{}, options.FeatureTypes...),
+			BreakingChangeMarkers: append([]string{}, options.BreakingChangeMarkers...),
+			MergeStrategy:         "all",
+		},
+		Branches:   []Branch{},
+		Skip:       []SkipRule{},
+		References: []Reference{},
+		lines:      map[string]int{},
+	}
+}
+
+// Validate checks the configuration. Returned error is of type *Error.
+func (c *Config) Validate() error {
+	if strings.ContainsAny(c.TagPrefix, " ~^:?*[\\") {
+		return c.errorf("tag_prefix", "invalid character in '%s'", c.TagPrefix)
+	}
+	switch c.Analyzer.Type {
+	case "", "angular":
+	case "rules":
+		if len(c.Analyzer.Rules) == 0 {
+			return c.errorf("analyzer.rules", "at least one rule is required")
+		}
+		for i, rule := range c.Analyzer.Rules {
+			if _, ok := bumpLevels[rule.Bump]; !ok {
+				return c.errorf(fmt.Sprintf("analyzer.rules[%d].bump", i), "unknown bump level '%s'", rule.Bump)
+			}
+		}
+		if _, err := rulecommit.New(c.rules()); err != nil {
+			if e, ok := err.(*rulecommit.InvalidRuleError); ok {
+				return c.errorf(fmt.Sprintf("analyzer.rules[%d].pattern", e.Index), "%s", e.Err)
+			}
+			return c.errorf("analyzer.rules", "%s", err)
+		}
+	default:
+		return c.errorf("analyzer.type", "unknown analyzer '%s'", c.Analyzer.Type)
+	}
+	if _, ok := mergeStrategies[c.Analyzer.MergeStrategy]; !ok {
+		return c.errorf("analyzer.merge_strategy", "unknown merge strategy '%s'", c.Analyzer.MergeStrategy)
+	}
+	if err := c.AnalyzerOptions().Validate(); err != nil {
+		if e, ok := err.(*angularcommit.InvalidMarkerError); ok {
+			return c.errorf(fmt.Sprintf("analyzer.breaking_change_markers[%d]", e.Index), "%s", e.Err)
+		}
+		return c.errorf("analyzer", "%s", err)
+	}
+	for i, branch := range c.Branches {
+		key := fmt.Sprintf("branches[%d]", i)
+		if len(branch.Name) == 0 {
+			return c.errorf(key, "name is required")
+		}
+		if _, err := path.Match(branch.Name, ""); err != nil {
+			return c.errorf(key+".name", "invalid pattern '%s'", branch.Name)
+		}
+		if len(branch.Channel) > 0 && !channelRe.MatchString(branch.Channel) {
+			return c.errorf(key+".channel", "invalid pre-release identifier '%s'", branch.Channel)
+		}
+	}
+	skip := make([]*regexp.Regexp, len(c.Skip))
+	for i, rule := range c.Skip {
+		key := fmt.Sprintf("skip[%d]", i)
+		if len(rule.Message) == 0 && len(rule.SHA) == 0 {
+			return c.errorf(key, "message or sha is required")
+		}
+		if len(rule.SHA) > 0 && !shaRe.MatchString(rule.SHA) {
+			return c.errorf(key+".sha", "invalid commit hash '%s'", rule.SHA)
+		}
+		if len(rule.Message) > 0 {
+			re, err := regexp.Compile(rule.Message)
+			if err != nil {
+				return c.errorf(key+".message", "%s", err)
+			}
+			skip[i] = re
+		}
+	}
+	c.skip = skip
+	for i, ref := range c.References {
+		key := fmt.Sprintf("references[%d]", i)
+		if len(ref.Pattern) == 0 {
+			return c.errorf(key, "pattern is required")
+		}
+		if _, err := regexp.Compile(ref.Pattern); err != nil {
+			return c.errorf(key+".pattern", "%s", err)
+		}
+	}
+	return nil
+}
+
+// AnalyzerOptions returns options for angularcommit.Analyzer
+func (c *Config) AnalyzerOptions() *angularcommit.Options {
+	return &angularcommit.Options{
+		ChoreTypes:            c.Analyzer.ChoreTypes,
+		FixTypes:              c.Analyzer.FixTypes,
+		FeatureTypes:          c.Analyzer.FeatureTypes,
+		BreakingChangeMarkers: c.Analyzer.BreakingChangeMarkers,
+		MergeStrategy:         mergeStrategies[c.Analyzer.MergeStrategy],
+		SquashBullets:         c.Analyzer.SquashBullets,
+		MultipleHeaders:       c.Analyzer.MultipleHeaders,
+		ReferencePatterns:     c.referencePatterns(),
+	}
+}
+
+// referencePatterns compiles references, invalid patterns are left out
+func (c *Config) referencePatterns() []semrel.ReferencePattern {
+	patterns := []semrel.ReferencePattern{}
+	for _, ref := range c.References {
+		p, err := semrel.NewReferencePattern(ref.Pattern, ref.URL)
+		if err != nil {
+			continue
+		}
+		patterns = append(patterns, p)
+	}
+	return patterns
+}
+
+// NewAnalyzer returns configured analyzer that ignores skipped commits
+func (c *Config) NewAnalyzer() semrel.ChangeAnalyzer {
+	if c.Analyzer.Type == "rules" {
+		analyzer, err := rulecommit.New(c.rules())
+		if err != nil {
+			semrel.Logf("WARNING: %s", err)
+			analyzer, _ = rulecommit.New(nil)
+		}
+		return &skipAnalyzer{
+			config:   c,
+			analyzer: analyzer,
+		}
+	}
+	return &skipAnalyzer{
+		config:   c,
+		analyzer: angularcommit.NewWithOptions(c.AnalyzerOptions()),
+	}
+}
+
+// rules returns the rules of "rules" analyzer
+func (c *Config) rules() []rulecommit.Rule {
+	rules := make([]rulecommit.Rule, len(c.Analyzer.Rules))
+	for i, rule := range c.Analyzer.Rules {
+		rules[i] = rulecommit.Rule{
+			Name:      rule.Name,
+			Pattern:   rule.Pattern,
+			BumpLevel: bumpLevels[rule.Bump],
+			Category:  rule.Category,
+		}
+	}
+	return rules
+}
+
+// TagPattern returns regular expression that matches release tags
+func (c *Config) TagPattern() *regexp.Regexp {
+	return regexp.MustCompile(`^` + regexp.QuoteMeta(c.TagPrefix) + versionPattern + `$`)
+}
+
+// Channel returns the release channel of branch. Empty channel means
+// stable releases. If no branches are configured, all branches make stable
+// releases, otherwise ok is false for branches that are not configured.
+func (c *Config) Channel(branch string) (channel string, ok bool) {
+	if len(c.Branches) == 0 {
+		return "", true
+	}
+	for _, b := range c.Branches {
+		if match, _ := path.Match(b.Name, branch); match {
+			return b.Channel, true
+		}
+	}
+	return "", false
+}
+
+// Skipped reports whether commit matches any of the skip rules
+func (c *Config) Skipped(commit *semrel.Commit) bool {
+	for i, rule := range c.Skip {
+		if len(rule.SHA) > 0 && strings.HasPrefix(commit.SHA, rule.SHA) {
+			return true
+		}
+		if len(rule.Message) == 0 {
+			continue
+		}
+		var re *regexp.Regexp
+		if i < len(c.skip) && c.skip[i] != nil {
+			re = c.skip[i]
+		} else if re, _ = regexp.Compile(rule.Message); re == nil {
+			continue
+		}
+		if re.MatchString(commit.Msg) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Config) errorf(key string, format string, args ...interface{}) error {
+	line := c.lines[key]
+	if line == 0 {
+		// fall back to the closest parent that has a line
+		for k := key; line == 0 && len(k) > 0; {
+			if i := strings.LastIndexAny(k, ".["); i >= 0 {
+				k = k[:i]
+			} else {
+				k = ""
+			}
+			line = c.lines[k]
+		}
+	}
+	return &Error{
+		File: c.file,
+		Key:  key,
+		Line: line,
+		Msg:  fmt.Sprintf(format, args...),
+	}
+}
+
+type skipAnalyzer struct {
+	config   *Config
+	analyzer semrel.ChangeAnalyzer
+}
+
+func (a *skipAnalyzer) Analyze(commit *semrel.Commit) ([]semrel.Change, error) {
+	if a.config.Skipped(commit) {
+		return []semrel.Change{}, nil
+	}
+	return a.analyzer.Analyze(commit)
+}
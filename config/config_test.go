@@ -0,0 +1,229 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/juranki/go-semrel/angularcommit"
+	"github.com/juranki/go-semrel/semrel"
+)
+
+const yamlConfig = `
+tag_prefix: v
+analyzer:
+  feature_types: [feat, feature]
+  merge_strategy: first-parent
+branches:
+  - name: master
+  - name: next
+    channel: beta
+skip:
+  - message: '\[skip release\]'
+`
+
+const jsonConfig = `{
+  "tag_prefix": "v",
+  "analyzer": {
+    "feature_types": ["feat", "feature"],
+    "merge_strategy": "first-parent"
+  },
+  "branches": [
+    {"name": "master"},
+    {"name": "next", "channel": "beta"}
+  ],
+  "skip": [{"message": "\\[skip release\\]"}]
+}`
+
+const tomlConfig = `
+tag_prefix = "v"
+
+[analyzer]
+feature_types = ["feat", "feature"]
+merge_strategy = "first-parent"
+
+[[branches]]
+name = "master"
+
+[[branches]]
+name = "next"
+channel = "beta"
+
+[[skip]]
+message = '\[skip release\]'
+`
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{".semrel.yaml", yamlConfig},
+		{".semrel.json", jsonConfig},
+		{".semrel.toml", tomlConfig},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := Parse(tt.name, []byte(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.TagPrefix != "v" {
+				t.Errorf("got tag prefix '%s', want 'v'", c.TagPrefix)
+			}
+			options := c.AnalyzerOptions()
+			if !reflect.DeepEqual(options.FeatureTypes, []string{"feat", "feature"}) {
+				t.Errorf("got feature types %v", options.FeatureTypes)
+			}
+			if !reflect.DeepEqual(options.FixTypes, angularcommit.DefaultOptions.FixTypes) {
+				t.Errorf("got fix types %v, want defaults", options.FixTypes)
+			}
+			if options.MergeStrategy != angularcommit.FirstParentCommits {
+				t.Errorf("got merge strategy %d", options.MergeStrategy)
+			}
+			want := []Branch{{Name: "master"}, {Name: "next", Channel: "beta"}}
+			if !reflect.DeepEqual(c.Branches, want) {
+				t.Errorf("got branches %+v, want %+v", c.Branches, want)
+			}
+			if !c.Skipped(&semrel.Commit{Msg: "fix: foo [skip release]"}) {
+				t.Error("commit should be skipped")
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{".semrel.yaml", "analyzer:\n  merge_strategy: foo\n", ".semrel.yaml:2: analyzer.merge_strategy: unknown merge strategy 'foo'"},
+		{".semrel.yaml", "analyzer:\n  squash: true\n", ".semrel.yaml:2: analyzer.squash: unknown key"},
+		{".semrel.yaml", "branches:\n  - name: master\n  - channel: beta\n", ".semrel.yaml:3: branches[1]: name is required"},
+		{".semrel.yaml", "analyzer:\n  squash_bullets: yes please\n", ".semrel.yaml:2: analyzer.squash_bullets: expected a boolean"},
+		{".semrel.yaml", "skip:\n  - sha: abc123\n  - message: '('\n", ".semrel.yaml:3: skip[1].message: error parsing regexp: missing closing ): `(`"},
+		{".semrel.json", "{\n  \"branches\": [\n    {\"name\": \"next\",\n     \"channel\": \"be ta\"}\n  ]\n}", ".semrel.json:4: branches[0].channel: invalid pre-release identifier 'be ta'"},
+		{".semrel.json", "{\n  \"tag_prefix\": 1\n}", ".semrel.json:2: tag_prefix: expected a string"},
+		{".semrel.toml", "[analyzer]\ntype = \"foo\"\n", ".semrel.toml:2: analyzer.type: unknown analyzer 'foo'"},
+		{".semrel.toml", "[analyzer]\n\nbreaking_change_markers = [\"(\"]\n", ".semrel.toml:3: analyzer.breaking_change_markers[0]: error parsing regexp: missing closing ): `(`"},
+		{".semrel.yaml", "references:\n  - url: https://example.com\n", ".semrel.yaml:2: references[0]: pattern is required"},
+		{".semrel.yaml", "analyzer:\n  type: rules\n  rules:\n    - pattern: '^fix'\n      bump: tiny\n", ".semrel.yaml:5: analyzer.rules[0].bump: unknown bump level 'tiny'"},
+		{".semrel.yaml", "analyzer:\n  type: rules\n  rules:\n    - pattern: '('\n      bump: patch\n", ".semrel.yaml:4: analyzer.rules[0].pattern: error parsing regexp: missing closing ): `(`"},
+		{".semrel.ini", "", ".semrel.ini: unknown configuration format"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			_, err := Parse(tt.name, []byte(tt.data))
+			if err == nil {
+				t.Fatal("got no error")
+			}
+			if err.Error() != tt.want {
+				t.Errorf("got '%s', want '%s'", err.Error(), tt.want)
+			}
+		})
+	}
+}
+
+func TestChannel(t *testing.T) {
+	c := Default()
+	if ch, ok := c.Channel("any"); !ok || ch != "" {
+		t.Errorf("got %s %t, want stable release", ch, ok)
+	}
+	c.Branches = []Branch{{Name: "master"}, {Name: "release/*", Channel: "rc"}}
+	tests := []struct {
+		branch  string
+		channel string
+		ok      bool
+	}{
+		{"master", "", true},
+		{"release/1.x", "rc", true},
+		{"feature/x", "", false},
+	}
+	for _, tt := range tests {
+		ch, ok := c.Channel(tt.branch)
+		if ch != tt.channel || ok != tt.ok {
+			t.Errorf("%s: got %s %t, want %s %t", tt.branch, ch, ok, tt.channel, tt.ok)
+		}
+	}
+}
+
+func TestTagPattern(t *testing.T) {
+	c := Default()
+	c.TagPrefix = "releases/"
+	re := c.TagPattern()
+	for tag, want := range map[string]bool{
+		"releases/1.2.3":      true,
+		"releases/v1.2.3-rc1": true,
+		"1.2.3":               false,
+		"releases/1.2":        false,
+	} {
+		if re.MatchString(tag) != want {
+			t.Errorf("%s: got %t, want %t", tag, !want, want)
+		}
+	}
+}
+
+func TestNewAnalyzer(t *testing.T) {
+	c, err := Parse(".semrel.yaml", []byte("skip:\n  - sha: abcdef\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := c.NewAnalyzer()
+	changes, err := a.Analyze(&semrel.Commit{Msg: "feat: x", SHA: "abcdef0123"})
+	if err != nil || len(changes) != 0 {
+		t.Errorf("got %v %v, want skipped commit", changes, err)
+	}
+	changes, err = a.Analyze(&semrel.Commit{Msg: "feat: x", SHA: "0123abcdef"})
+	if err != nil || len(changes) != 1 {
+		t.Errorf("got %v %v, want one change", changes, err)
+	}
+}
+
+func TestNewAnalyzer_Rules(t *testing.T) {
+	c, err := Parse(".semrel.yaml", []byte("analyzer:\n  type: rules\n  rules:\n    - name: hotfix\n      pattern: '^HOTFIX '\n      bump: patch\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	changes, err := c.NewAnalyzer().Analyze(&semrel.Commit{Msg: "HOTFIX crash"})
+	if err != nil || len(changes) != 1 || changes[0].BumpLevel() != semrel.BumpPatch {
+		t.Errorf("got %v %v, want one fix", changes, err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "semrel-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	c, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(c.AnalyzerOptions().FeatureTypes, angularcommit.DefaultOptions.FeatureTypes) {
+		t.Errorf("got %+v, want defaults", c.Analyzer)
+	}
+
+	write := func(name, data string) {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(".semrel.toml", tomlConfig)
+	c, err = Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.TagPrefix != "v" {
+		t.Errorf("got tag prefix '%s', want 'v'", c.TagPrefix)
+	}
+
+	write(".semrel.yaml", yamlConfig)
+	if _, err := Load(dir); err == nil {
+		t.Error("want error with multiple configuration files")
+	}
+}
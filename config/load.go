@@ -0,0 +1,373 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	toml "github.com/pelletier/go-toml"
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Filenames of configuration files searched from repository root
+var Filenames = []string{".semrel.yaml", ".semrel.yml", ".semrel.json", ".semrel.toml"}
+
+// Find returns path of the configuration file in directory root,
+// or empty string if there is none. It's an error to have more than one
+// configuration file.
+func Find(root string) (string, error) {
+	found := []string{}
+	for _, name := range Filenames {
+		p := filepath.Join(root, name)
+		info, err := os.Stat(p)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		if !info.IsDir() {
+			found = append(found, p)
+		}
+	}
+	if len(found) > 1 {
+		return "", errors.Errorf("multiple configuration files: %s", strings.Join(found, ", "))
+	}
+	if len(found) == 0 {
+		return "", nil
+	}
+	return found[0], nil
+}
+
+// Load reads and validates configuration from repository root. Default
+// configuration is returned when there is no configuration file.
+func Load(root string) (*Config, error) {
+	p, err := Find(root)
+	if err != nil {
+		return nil, err
+	}
+	if len(p) == 0 {
+		return Default(), nil
+	}
+	return LoadFile(p)
+}
+
+// LoadFile reads and validates configuration file. Format is selected
+// by file extension.
+func LoadFile(path string) (*Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(path, data)
+}
+
+// Parse parses and validates configuration. Format is selected by the
+// extension of name, which is also used in error messages.
+func Parse(name string, data []byte) (*Config, error) {
+	var root *node
+	var err error
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".yaml", ".yml":
+		root, err = parseYAML(data)
+	case ".json":
+		root, err = parseJSON(data)
+	case ".toml":
+		root, err = parseTOML(data)
+	default:
+		return nil, &Error{File: name, Msg: "unknown configuration format"}
+	}
+	if err != nil {
+		if e, ok := err.(*Error); ok {
+			e.File = name
+			return nil, e
+		}
+		return nil, &Error{File: name, Msg: err.Error()}
+	}
+	c := Default()
+	c.file = name
+	if root.kind != nullNode {
+		if err := decode(root, "", reflect.ValueOf(c).Elem(), c.lines); err != nil {
+			err.File = name
+			return nil, err
+		}
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+type nodeKind int
+
+const (
+	nullNode nodeKind = iota
+	scalarNode
+	mapNode
+	listNode
+)
+
+// node is a format independent document tree that remembers line numbers
+type node struct {
+	kind   nodeKind
+	line   int
+	value  interface{}
+	keys   []string
+	fields map[string]*node
+	items  []*node
+}
+
+func newMap(line int) *node {
+	return &node{kind: mapNode, line: line, fields: map[string]*node{}}
+}
+
+func (n *node) set(key string, value *node) {
+	if _, exists := n.fields[key]; !exists {
+		n.keys = append(n.keys, key)
+	}
+	n.fields[key] = value
+}
+
+func decode(n *node, key string, v reflect.Value, lines map[string]int) *Error {
+	lines[key] = n.line
+	fail := func(format string, args ...interface{}) *Error {
+		return &Error{Key: key, Line: n.line, Msg: fmt.Sprintf(format, args...)}
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		if n.kind != mapNode {
+			return fail("expected a table")
+		}
+		fields := map[string]int{}
+		for i := 0; i < v.NumField(); i++ {
+			if tag := v.Type().Field(i).Tag.Get("config"); len(tag) > 0 {
+				fields[tag] = i
+			}
+		}
+		for _, k := range n.keys {
+			child := n.fields[k]
+			childKey := k
+			if len(key) > 0 {
+				childKey = key + "." + k
+			}
+			i, ok := fields[k]
+			if !ok {
+				return &Error{Key: childKey, Line: child.line, Msg: "unknown key"}
+			}
+			if child.kind == nullNode {
+				continue
+			}
+			if err := decode(child, childKey, v.Field(i), lines); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice:
+		if n.kind != listNode {
+			return fail("expected a list")
+		}
+		s := reflect.MakeSlice(v.Type(), len(n.items), len(n.items))
+		for i, item := range n.items {
+			if err := decode(item, fmt.Sprintf("%s[%d]", key, i), s.Index(i), lines); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+	case reflect.String:
+		s, ok := n.value.(string)
+		if n.kind != scalarNode || !ok {
+			return fail("expected a string")
+		}
+		v.SetString(s)
+	case reflect.Bool:
+		b, ok := n.value.(bool)
+		if n.kind != scalarNode || !ok {
+			return fail("expected a boolean")
+		}
+		v.SetBool(b)
+	default:
+		return fail("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+func parseYAML(data []byte) (*node, error) {
+	doc := yaml.Node{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return &node{kind: nullNode}, nil
+	}
+	return fromYAML(doc.Content[0])
+}
+
+func fromYAML(y *yaml.Node) (*node, error) {
+	switch y.Kind {
+	case yaml.AliasNode:
+		return fromYAML(y.Alias)
+	case yaml.MappingNode:
+		n := newMap(y.Line)
+		for i := 0; i+1 < len(y.Content); i += 2 {
+			k, v := y.Content[i], y.Content[i+1]
+			child, err := fromYAML(v)
+			if err != nil {
+				return nil, err
+			}
+			// errors point at the key rather than the value
+			child.line = k.Line
+			n.set(k.Value, child)
+		}
+		return n, nil
+	case yaml.SequenceNode:
+		n := &node{kind: listNode, line: y.Line}
+		for _, item := range y.Content {
+			child, err := fromYAML(item)
+			if err != nil {
+				return nil, err
+			}
+			n.items = append(n.items, child)
+		}
+		return n, nil
+	case yaml.ScalarNode:
+		if y.Tag == "!!null" {
+			return &node{kind: nullNode, line: y.Line}, nil
+		}
+		var v interface{}
+		if err := y.Decode(&v); err != nil {
+			return nil, &Error{Line: y.Line, Msg: err.Error()}
+		}
+		return &node{kind: scalarNode, line: y.Line, value: v}, nil
+	}
+	return nil, &Error{Line: y.Line, Msg: "unsupported YAML node"}
+}
+
+func parseJSON(data []byte) (*node, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return &node{kind: nullNode}, nil
+	}
+	newlines := []int{}
+	for i, b := range data {
+		if b == '\n' {
+			newlines = append(newlines, i)
+		}
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	line := func() int {
+		return sort.SearchInts(newlines, int(dec.InputOffset())) + 1
+	}
+	var value func(tok json.Token) (*node, error)
+	value = func(tok json.Token) (*node, error) {
+		l := line()
+		switch t := tok.(type) {
+		case json.Delim:
+			if t == '{' {
+				n := newMap(l)
+				for dec.More() {
+					k, err := dec.Token()
+					if err != nil {
+						return nil, err
+					}
+					kl := line()
+					v, err := dec.Token()
+					if err != nil {
+						return nil, err
+					}
+					child, err := value(v)
+					if err != nil {
+						return nil, err
+					}
+					child.line = kl
+					n.set(k.(string), child)
+				}
+				_, err := dec.Token()
+				return n, err
+			}
+			if t == '[' {
+				n := &node{kind: listNode, line: l}
+				for dec.More() {
+					v, err := dec.Token()
+					if err != nil {
+						return nil, err
+					}
+					child, err := value(v)
+					if err != nil {
+						return nil, err
+					}
+					n.items = append(n.items, child)
+				}
+				_, err := dec.Token()
+				return n, err
+			}
+		case nil:
+			return &node{kind: nullNode, line: l}, nil
+		}
+		return &node{kind: scalarNode, line: l, value: tok}, nil
+	}
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, jsonError(err, line())
+	}
+	root, err := value(tok)
+	if err != nil {
+		return nil, jsonError(err, line())
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, &Error{Line: line(), Msg: "unexpected data after configuration"}
+	}
+	return root, nil
+}
+
+func jsonError(err error, line int) error {
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return &Error{Line: line, Msg: err.Error()}
+}
+
+func parseTOML(data []byte) (*node, error) {
+	tree, err := toml.LoadBytes(data)
+	if err != nil {
+		return nil, err
+	}
+	return fromTOML(tree), nil
+}
+
+func fromTOML(tree *toml.Tree) *node {
+	n := newMap(tree.Position().Line)
+	keys := tree.Keys()
+	sort.Slice(keys, func(i, j int) bool {
+		return tree.GetPositionPath([]string{keys[i]}).Line < tree.GetPositionPath([]string{keys[j]}).Line
+	})
+	for _, k := range keys {
+		line := tree.GetPositionPath([]string{k}).Line
+		var child *node
+		switch v := tree.GetPath([]string{k}).(type) {
+		case *toml.Tree:
+			child = fromTOML(v)
+		case []*toml.Tree:
+			child = &node{kind: listNode}
+			for _, t := range v {
+				child.items = append(child.items, fromTOML(t))
+			}
+		case []interface{}:
+			child = &node{kind: listNode}
+			for _, item := range v {
+				child.items = append(child.items, &node{kind: scalarNode, line: line, value: item})
+			}
+		default:
+			child = &node{kind: scalarNode, value: v}
+		}
+		child.line = line
+		n.set(k, child)
+	}
+	return n
+}
@@ -0,0 +1,210 @@
+// Package gitmojicommit analyzes commit messages that start with gitmoji
+//
+// https://gitmoji.dev
+//
+// The head of the message is one or more gitmojis, either as emoji or
+// shortcode, an optional scope in parenthesis and the subject:
+//
+//	✨ (api): add endpoint
+//	:bug: fix crash
+package gitmojicommit
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/juranki/go-semrel/semrel"
+)
+
+var (
+	scopeHead = regexp.MustCompile(`^\(([^\)]+)\):?\s*`)
+	shortcode = regexp.MustCompile(`^:[a-z0-9_+-]+:`)
+	// DefaultOptions for gitmoji Analyzer
+	DefaultOptions = &Options{Gitmojis: DefaultGitmojis}
+	// DefaultGitmojis follow the semver field of gitmoji.dev. Gitmojis that
+	// don't affect the version are in category "other".
+	DefaultGitmojis = []Gitmoji{
+		{"💥", ":boom:", semrel.BumpMajor, "breaking"},
+		{"✨", ":sparkles:", semrel.BumpMinor, "feature"},
+		{"🐛", ":bug:", semrel.BumpPatch, "fix"},
+		{"🚑", ":ambulance:", semrel.BumpPatch, "fix"},
+		{"🩹", ":adhesive_bandage:", semrel.BumpPatch, "fix"},
+		{"🔒", ":lock:", semrel.BumpPatch, "fix"},
+		{"⚡", ":zap:", semrel.BumpPatch, "fix"},
+		{"💄", ":lipstick:", semrel.BumpPatch, "fix"},
+		{"⬆", ":arrow_up:", semrel.BumpPatch, "fix"},
+		{"⬇", ":arrow_down:", semrel.BumpPatch, "fix"},
+		{"📌", ":pushpin:", semrel.BumpPatch, "fix"},
+		{"🌐", ":globe_with_meridians:", semrel.BumpPatch, "fix"},
+		{"✏", ":pencil2:", semrel.BumpPatch, "fix"},
+		{"♿", ":wheelchair:", semrel.BumpPatch, "fix"},
+		{"💬", ":speech_balloon:", semrel.BumpPatch, "fix"},
+		{"🥅", ":goal_net:", semrel.BumpPatch, "fix"},
+		{"🎨", ":art:", semrel.NoBump, "other"},
+		{"🔥", ":fire:", semrel.NoBump, "other"},
+		{"📝", ":memo:", semrel.NoBump, "other"},
+		{"🚀", ":rocket:", semrel.NoBump, "other"},
+		{"🎉", ":tada:", semrel.NoBump, "other"},
+		{"✅", ":white_check_mark:", semrel.NoBump, "other"},
+		{"🔖", ":bookmark:", semrel.NoBump, "other"},
+		{"🚨", ":rotating_light:", semrel.NoBump, "other"},
+		{"🚧", ":construction:", semrel.NoBump, "other"},
+		{"💚", ":green_heart:", semrel.NoBump, "other"},
+		{"👷", ":construction_worker:", semrel.NoBump, "other"},
+		{"♻", ":recycle:", semrel.NoBump, "other"},
+		{"🔧", ":wrench:", semrel.NoBump, "other"},
+		{"🔨", ":hammer:", semrel.NoBump, "other"},
+		{"🔀", ":twisted_rightwards_arrows:", semrel.NoBump, "other"},
+		{"⏪", ":rewind:", semrel.NoBump, "other"},
+		{"🙈", ":see_no_evil:", semrel.NoBump, "other"},
+	}
+)
+
+// Gitmoji maps an emoji to bump level and release notes category
+type Gitmoji struct {
+	// Emoji without variation selector, e.g. ✨
+	Emoji string
+	// Code is the shortcode, e.g. :sparkles:
+	Code      string
+	BumpLevel semrel.BumpLevel
+	// Category of the change, derived from BumpLevel when empty
+	Category string
+}
+
+// Options control how gitmoji analyzer behaves
+type Options struct {
+	Gitmojis []Gitmoji
+}
+
+// Analyzer is a semrel.Analyzer instance that parses commits
+// that start with gitmoji
+type Analyzer struct {
+	options *Options
+}
+
+// NewWithOptions initializes Analyzer with options provided
+func NewWithOptions(options *Options) *Analyzer {
+	return &Analyzer{
+		options: options,
+	}
+}
+
+// New initializes Analyzer with DefaultOptions
+func New() *Analyzer {
+	return &Analyzer{}
+}
+
+func (analyzer *Analyzer) getOptions() *Options {
+	if analyzer.options == nil {
+		return DefaultOptions
+	}
+	return analyzer.options
+}
+
+// Lint checks that message starts with known gitmoji and has a subject
+func (analyzer *Analyzer) Lint(message string) []error {
+	c, rest := parseHead(message, analyzer.getOptions())
+	if c == nil {
+		if code := shortcode.FindString(rest); len(code) > 0 {
+			return []error{fmt.Errorf("unknown gitmoji '%s'", code)}
+		}
+		return []error{errors.New("invalid message head")}
+	}
+	if len(c.Subject) == 0 {
+		return []error{errors.New("missing subject")}
+	}
+	return []error{}
+}
+
+// Analyze implements semrel.Analyzer interface for gitmojicommit.Analyzer.
+// Commits that don't start with gitmoji are ignored.
+func (analyzer *Analyzer) Analyze(commit *semrel.Commit) ([]semrel.Change, error) {
+	changes := []semrel.Change{}
+	c, _ := parseHead(commit.Msg, analyzer.getOptions())
+	if c != nil {
+		c.Hash = commit.SHA
+		c.commit = *commit
+		changes = append(changes, c)
+	}
+	return changes, nil
+}
+
+// Change captures commit message analysis
+type Change struct {
+	// Gitmoji with the highest bump level in the head
+	Gitmoji Gitmoji
+	Scope   string
+	Subject string
+	Hash    string
+	commit  semrel.Commit
+}
+
+// Category implements semrel.Change interface
+func (c *Change) Category() string {
+	if len(c.Gitmoji.Category) == 0 {
+		return semrel.DefaultCategory(c.Gitmoji.BumpLevel)
+	}
+	return c.Gitmoji.Category
+}
+
+// BumpLevel implements semrel.Change interface
+func (c *Change) BumpLevel() semrel.BumpLevel {
+	return c.Gitmoji.BumpLevel
+}
+
+// PreReleased implements semrel.Change interface
+func (c *Change) PreReleased() bool {
+	return c.commit.PreReleased
+}
+
+// Describe implements semrel.Describer interface
+func (c *Change) Describe() semrel.Description {
+	return semrel.Description{
+		Scope:   c.Scope,
+		Subject: c.Subject,
+		SHA:     c.Hash,
+	}
+}
+
+// parseHead returns the change described by the head line of message,
+// or nil and the unparsed rest of the head if it doesn't start with gitmoji
+func parseHead(message string, options *Options) (*Change, string) {
+	head := strings.TrimSpace(strings.SplitN(strings.Replace(message, "\r", "", -1), "\n", 2)[0])
+	var change *Change
+	for {
+		gitmoji, rest, ok := nextGitmoji(head, options.Gitmojis)
+		if !ok {
+			break
+		}
+		if change == nil || gitmoji.BumpLevel > change.Gitmoji.BumpLevel {
+			change = &Change{Gitmoji: gitmoji}
+		}
+		head = strings.TrimSpace(rest)
+	}
+	if change == nil {
+		return nil, head
+	}
+	if match := scopeHead.FindStringSubmatch(head); len(match) > 0 {
+		change.Scope = strings.TrimSpace(match[1])
+		head = head[len(match[0]):]
+	}
+	change.Subject = strings.TrimSpace(head)
+	return change, ""
+}
+
+// nextGitmoji returns the gitmoji at the start of text
+func nextGitmoji(text string, gitmojis []Gitmoji) (Gitmoji, string, bool) {
+	for _, g := range gitmojis {
+		for _, prefix := range []string{g.Emoji, g.Code} {
+			if len(prefix) == 0 || !strings.HasPrefix(text, prefix) {
+				continue
+			}
+			// emoji may be followed by variation selector
+			rest := strings.TrimPrefix(text[len(prefix):], "\ufe0f")
+			return g, rest, true
+		}
+	}
+	return Gitmoji{}, text, false
+}
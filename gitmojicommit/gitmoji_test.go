@@ -0,0 +1,87 @@
+package gitmojicommit
+
+import (
+	"testing"
+
+	"github.com/juranki/go-semrel/semrel"
+)
+
+func TestParseHead(t *testing.T) {
+	cases := []struct {
+		msg      string
+		category string
+		scope    string
+		subject  string
+	}{
+		{"✨ add endpoint", "feature", "", "add endpoint"},
+		{":sparkles: (api): add endpoint\n\nbody", "feature", "api", "add endpoint"},
+		{"🐛(ui) fix crash", "fix", "ui", "fix crash"},
+		{"⚡️ faster", "fix", "", "faster"},
+		{":bug: 💥 change api", "breaking", "", "change api"},
+		{"📝 docs", "other", "", "docs"},
+		{"add endpoint", "", "", ""},
+		{":unknown: x", "", "", ""},
+	}
+	for _, c := range cases {
+		change, _ := parseHead(c.msg, DefaultOptions)
+		if change == nil {
+			if len(c.category) > 0 {
+				t.Errorf("'%s': not parsed", c.msg)
+			}
+			continue
+		}
+		if change.Category() != c.category || change.Scope != c.scope || change.Subject != c.subject {
+			t.Errorf("'%s': got %s/%s/%s, want %s/%s/%s", c.msg,
+				change.Category(), change.Scope, change.Subject, c.category, c.scope, c.subject)
+		}
+	}
+}
+
+func TestAnalyzer(t *testing.T) {
+	release, err := semrel.Release(&semrel.VCSData{
+		UnreleasedCommits: []semrel.Commit{
+			{Msg: ":sparkles: add x", SHA: "1"},
+			{Msg: "🐛 fix y", SHA: "2"},
+			{Msg: "plain message", SHA: "3"},
+		},
+	}, New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if release.BumpLevel != semrel.BumpMinor || len(release.Changes["feature"]) != 1 || len(release.Changes["fix"]) != 1 {
+		t.Errorf("got %+v", release)
+	}
+
+	custom := NewWithOptions(&Options{Gitmojis: []Gitmoji{{"🚀", ":rocket:", semrel.BumpPatch, "deploy"}}})
+	changes, _ := custom.Analyze(&semrel.Commit{Msg: "🚀 deploy", SHA: "4"})
+	if len(changes) != 1 || changes[0].Category() != "deploy" || changes[0].BumpLevel() != semrel.BumpPatch {
+		t.Errorf("got %+v", changes)
+	}
+	if changes, _ := custom.Analyze(&semrel.Commit{Msg: "✨ add"}); len(changes) != 0 {
+		t.Errorf("got %+v", changes)
+	}
+}
+
+func TestLint(t *testing.T) {
+	cases := []struct {
+		msg string
+		err string
+	}{
+		{"✨ add endpoint", ""},
+		{":bug: fix crash", ""},
+		{"add endpoint", "invalid message head"},
+		{":sparkle: add endpoint", "unknown gitmoji ':sparkle:'"},
+		{":sparkles:", "missing subject"},
+	}
+	a := New()
+	for _, c := range cases {
+		errs := a.Lint(c.msg)
+		got := ""
+		if len(errs) > 0 {
+			got = errs[0].Error()
+		}
+		if got != c.err {
+			t.Errorf("'%s': got '%s', want '%s'", c.msg, got, c.err)
+		}
+	}
+}
@@ -3,8 +3,10 @@ module github.com/juranki/go-semrel
 require (
 	github.com/blang/semver v3.5.1+incompatible
 	github.com/google/go-cmp v0.3.1 // indirect
+	github.com/pelletier/go-toml v1.9.5
 	github.com/pkg/errors v0.8.1
 	golang.org/x/sys v0.0.0-20190813064441-fde4db37ae7a // indirect
 	gopkg.in/src-d/go-billy.v4 v4.3.2
 	gopkg.in/src-d/go-git.v4 v4.13.1
+	gopkg.in/yaml.v3 v3.0.1
 )
@@ -0,0 +1,275 @@
+package inspectgit
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/juranki/go-semrel/semrel"
+	"github.com/pkg/errors"
+	git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	fdiff "gopkg.in/src-d/go-git.v4/plumbing/format/diff"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// DedupOptions control Deduplicate
+type DedupOptions struct {
+	// Subjects matches commits also by normalized subject line when their
+	// patches differ, e.g. cherry-picks with resolved conflicts
+	Subjects bool
+	// Released leaves out commits whose patch was released by a version
+	// tag on another branch, i.e. a tag that is not an ancestor of Target
+	Released bool
+	// Prefix of version tags, as in VCSDataWithPrefix
+	Prefix string
+	// Target is the revision that data was collected from, HEAD when empty
+	Target string
+}
+
+// Duplicate is an unreleased commit that Deduplicate left out
+type Duplicate struct {
+	Commit semrel.Commit
+	// Of is the SHA of the unreleased commit that was kept,
+	// empty when the patch was released
+	Of string
+	// Tag that released the patch on another branch, nil when Of is set
+	Tag *semrel.Tag
+}
+
+// Deduplicate removes the unreleased commits of data that repeat the patch
+// of an earlier unreleased commit, e.g. cherry-picks and patches that were
+// merged through several branches. The earliest commit is kept. Merge
+// commits are never removed. Patches are identified by PatchID.
+func Deduplicate(r *git.Repository, data *semrel.VCSData, options *DedupOptions) ([]Duplicate, error) {
+	if options == nil {
+		options = &DedupOptions{}
+	}
+	released := map[string]*semrel.Tag{}
+	if options.Released {
+		var err error
+		if released, err = getReleasedPatches(r, options); err != nil {
+			return nil, err
+		}
+	}
+
+	duplicates := []Duplicate{}
+	kept := []semrel.Commit{}
+	patches := map[string]string{}
+	subjects := map[string]string{}
+	for _, commit := range data.UnreleasedCommits {
+		if commit.IsMerge {
+			kept = append(kept, commit)
+			continue
+		}
+		c, err := r.CommitObject(plumbing.NewHash(commit.SHA))
+		if err != nil {
+			return nil, err
+		}
+		id, err := PatchID(c)
+		if err != nil {
+			return nil, err
+		}
+		subject := normalizeSubject(commit.Msg)
+		if tag, ok := released[id]; ok {
+			semrel.Logf("%.7s: patch released in %s", commit.SHA, tag.Name)
+			duplicates = append(duplicates, Duplicate{Commit: commit, Tag: tag})
+			continue
+		}
+		of, ok := "", false
+		if len(id) > 0 {
+			of, ok = patches[id]
+		}
+		if !ok && options.Subjects && len(subject) > 0 {
+			of, ok = subjects[subject]
+		}
+		if ok {
+			semrel.Logf("%.7s: duplicate of %.7s", commit.SHA, of)
+			duplicates = append(duplicates, Duplicate{Commit: commit, Of: of})
+			continue
+		}
+		if len(id) > 0 {
+			patches[id] = commit.SHA
+		}
+		if len(subject) > 0 {
+			subjects[subject] = commit.SHA
+		}
+		kept = append(kept, commit)
+	}
+	data.UnreleasedCommits = kept
+	return duplicates, nil
+}
+
+// getReleasedPatches returns the patch IDs of commits that are reachable
+// from version tags but not from target, mapped to the lowest such tag
+func getReleasedPatches(r *git.Repository, options *DedupOptions) (map[string]*semrel.Tag, error) {
+	target := options.Target
+	if len(target) == 0 {
+		target = "HEAD"
+	}
+	head, err := resolveCommit(r, target)
+	if err != nil {
+		return nil, err
+	}
+	ancestors, err := newCommits(head, map[plumbing.Hash]bool{})
+	if err != nil {
+		return nil, err
+	}
+	seen := map[plumbing.Hash]bool{}
+	for hash := range ancestors {
+		seen[hash] = true
+	}
+	versions, err := getVersionTags(r, options.Prefix)
+	if err != nil {
+		return nil, err
+	}
+	tags := []semrel.Tag{}
+	for _, tag := range versions {
+		if !seen[plumbing.NewHash(tag.SHA)] {
+			tags = append(tags, tag)
+		}
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Version.LT(tags[j].Version) })
+
+	released := map[string]*semrel.Tag{}
+	for i := range tags {
+		c, err := r.CommitObject(plumbing.NewHash(tags[i].SHA))
+		if err == plumbing.ErrObjectNotFound {
+			// refs of annotated tags point to tag objects
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, tags[i].Name)
+		}
+		commits, err := newCommits(c, seen)
+		if err != nil {
+			return nil, err
+		}
+		for hash, commit := range commits {
+			seen[hash] = true
+			if commit.NumParents() > 1 {
+				continue
+			}
+			id, err := PatchID(commit)
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := released[id]; !ok && len(id) > 0 {
+				released[id] = &tags[i]
+			}
+		}
+	}
+	return released, nil
+}
+
+// patchContext is the number of unchanged lines around changes that are
+// part of the patch, as in the hunks of `git diff`
+const patchContext = 3
+
+// PatchID returns an identifier of the changes of commit compared to its
+// first parent. As with `git patch-id`, the changed lines and their context
+// are included, but line numbers and whitespace are ignored, so the same
+// patch applied on different bases has the same ID. The ID is empty for
+// commits that change nothing.
+func PatchID(c *object.Commit) (string, error) {
+	tree, err := c.Tree()
+	if err != nil {
+		return "", err
+	}
+	var parentTree *object.Tree
+	if c.NumParents() > 0 {
+		parent, err := c.Parent(0)
+		if err != nil {
+			return "", err
+		}
+		if parentTree, err = parent.Tree(); err != nil {
+			return "", err
+		}
+	}
+	changes, err := object.DiffTree(parentTree, tree)
+	if err != nil {
+		return "", err
+	}
+	if len(changes) == 0 {
+		return "", nil
+	}
+	patch, err := changes.Patch()
+	if err != nil {
+		return "", err
+	}
+	h := sha1.New()
+	for _, fp := range patch.FilePatches() {
+		from, to := fp.Files()
+		fmt.Fprintf(h, "diff %s %s\n", filePath(from), filePath(to))
+		if fp.IsBinary() {
+			fmt.Fprintf(h, "binary %s %s\n", fileHash(from), fileHash(to))
+			continue
+		}
+		chunks := fp.Chunks()
+		for i, chunk := range chunks {
+			lines := strings.SplitAfter(chunk.Content(), "\n")
+			op := " "
+			switch chunk.Type() {
+			case fdiff.Add:
+				op = "+"
+			case fdiff.Delete:
+				op = "-"
+			default:
+				lines = contextLines(lines, i > 0, i < len(chunks)-1)
+			}
+			for _, line := range lines {
+				if line = strings.Join(strings.Fields(line), ""); len(line) > 0 {
+					fmt.Fprintf(h, "%s%s\n", op, line)
+				}
+			}
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// contextLines returns the unchanged lines that are context of the change
+// before them, the change after them, or both
+func contextLines(lines []string, before bool, after bool) []string {
+	if len(lines) > 0 && len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+	if before && after && len(lines) <= 2*patchContext {
+		return lines
+	}
+	context := []string{}
+	if before && len(lines) > patchContext {
+		context = append(context, lines[:patchContext]...)
+	} else if before {
+		context = append(context, lines...)
+	}
+	if after && len(lines) > patchContext {
+		context = append(context, lines[len(lines)-patchContext:]...)
+	} else if after {
+		context = append(context, lines...)
+	}
+	return context
+}
+
+func filePath(f fdiff.File) string {
+	if f == nil {
+		return "/dev/null"
+	}
+	return f.Path()
+}
+
+func fileHash(f fdiff.File) string {
+	if f == nil {
+		return ""
+	}
+	return f.Hash().String()
+}
+
+// normalizeSubject returns the lower case head line of message without
+// extra whitespace or trailing period
+func normalizeSubject(message string) string {
+	head := strings.SplitN(strings.TrimSpace(message), "\n", 2)[0]
+	head = strings.Join(strings.Fields(strings.ToLower(head)), " ")
+	return strings.TrimSuffix(head, ".")
+}
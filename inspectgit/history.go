@@ -0,0 +1,224 @@
+package inspectgit
+
+import (
+	"sort"
+
+	"github.com/blang/semver"
+	"github.com/juranki/go-semrel/semrel"
+	git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// Release in the history of a repository
+type Release struct {
+	Tag semrel.Tag
+	// Previous release, nil for the first release. It is the highest
+	// earlier version whose commit is an ancestor of the tag. Stable
+	// releases follow stable releases, pre-releases follow any release.
+	Previous *semrel.Tag
+	// Commits reachable from the tag but not from previous releases,
+	// sorted by time. Commits of a stable release that were included in
+	// pre-releases are marked PreReleased.
+	Commits    []semrel.Commit
+	repository *semrel.RepositoryInfo
+}
+
+// VCSData returns the release as input of semrel.Release, e.g. to
+// regenerate its release notes
+func (r *Release) VCSData() *semrel.VCSData {
+	data := &semrel.VCSData{
+		CurrentVersion:    semver.MustParse("0.0.0"),
+		UnreleasedCommits: r.Commits,
+		Time:              r.Tag.Date,
+		SHA:               r.Tag.SHA,
+		Repository:        r.repository,
+		PreviousRelease:   r.Previous,
+	}
+	if r.Previous != nil {
+		data.CurrentVersion = r.Previous.Version
+	}
+	return data
+}
+
+// History returns all releases of repository at path in version order,
+// including pre-releases. Prefix is handled as in VCSDataWithPrefix.
+func History(path string, prefix string) ([]Release, error) {
+	r, err := Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return RepositoryHistory(r, prefix)
+}
+
+// RepositoryHistory is History for an open repository
+func RepositoryHistory(r *git.Repository, prefix string) ([]Release, error) {
+	versions, err := getVersionTags(r, prefix)
+	if err != nil {
+		return nil, err
+	}
+	releases, err := getHistory(r, versions)
+	if err != nil {
+		return nil, err
+	}
+	repository := getRepositoryInfo(r)
+	for i := range releases {
+		releases[i].repository = repository
+	}
+	return releases, nil
+}
+
+// getHistory returns a release for each version in versions. Several
+// versions may tag the same commit, e.g. a promoted release candidate.
+func getHistory(r *git.Repository, versions []semrel.Tag) ([]Release, error) {
+	type tagged struct {
+		tag    semrel.Tag
+		commit *object.Commit
+	}
+	tags := []tagged{}
+	for _, tag := range versions {
+		c, err := r.CommitObject(plumbing.NewHash(tag.SHA))
+		if err != nil {
+			// refs of annotated tags point to tag objects
+			continue
+		}
+		if !tag.Annotated {
+			tag.Date = c.Committer.When
+		}
+		tags = append(tags, tagged{tag, c})
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].tag.Version.LT(tags[j].tag.Version) })
+
+	// ancestors of stable releases, and of all releases processed so far.
+	// As releases are processed in version order, they are the commits of
+	// previous releases.
+	stableSeen := map[plumbing.Hash]bool{}
+	allSeen := map[plumbing.Hash]bool{}
+	// releases by commit, and the releases that each release contains
+	byCommit := map[plumbing.Hash][]int{}
+	contains := [][]int{}
+	releases := []Release{}
+	for i := range tags {
+		tag := tags[i].tag
+		if n := len(releases); n > 0 && releases[n-1].Tag.Version.Equals(tag.Version) {
+			// the same version with another prefix
+			continue
+		}
+		stable := len(tag.Version.Pre) == 0
+		ancestors, err := releasedAncestors(tags[i].commit, byCommit, contains)
+		if err != nil {
+			return nil, err
+		}
+		release := Release{Tag: tag}
+		for j := len(releases) - 1; j >= 0; j-- {
+			if ancestors[j] && (!stable || len(releases[j].Tag.Version.Pre) == 0) {
+				previous := releases[j].Tag
+				release.Previous = &previous
+				break
+			}
+		}
+		seen := allSeen
+		if stable {
+			seen = stableSeen
+		}
+		commits, err := newCommits(tags[i].commit, seen)
+		if err != nil {
+			return nil, err
+		}
+		firstParents := firstParentLine(tags[i].commit, commits)
+		for hash, c := range commits {
+			release.Commits = append(release.Commits, semrel.Commit{
+				Msg:           c.Message,
+				SHA:           hash.String(),
+				Time:          c.Author.When,
+				PreReleased:   stable && allSeen[hash],
+				IsMerge:       c.NumParents() > 1,
+				IsFirstParent: firstParents[hash],
+			})
+			allSeen[hash] = true
+			if stable {
+				stableSeen[hash] = true
+			}
+		}
+		sort.Sort(semrel.ByTime(release.Commits))
+		byCommit[tags[i].commit.Hash] = append(byCommit[tags[i].commit.Hash], len(releases))
+		list := []int{}
+		for j := range ancestors {
+			list = append(list, j)
+		}
+		contains = append(contains, list)
+		releases = append(releases, release)
+	}
+	return releases, nil
+}
+
+// releasedAncestors returns the indices of releases whose commit is head or
+// its ancestor. ByCommit maps commits to releases, contains lists the
+// released ancestors of each release. The walk stops at released commits.
+func releasedAncestors(head *object.Commit, byCommit map[plumbing.Hash][]int, contains [][]int) (map[int]bool, error) {
+	ancestors := map[int]bool{}
+	visited := map[plumbing.Hash]bool{}
+	stack := []*object.Commit{head}
+	for len(stack) > 0 {
+		c := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if visited[c.Hash] {
+			continue
+		}
+		visited[c.Hash] = true
+		if released, ok := byCommit[c.Hash]; ok {
+			for _, i := range released {
+				ancestors[i] = true
+				for _, j := range contains[i] {
+					ancestors[j] = true
+				}
+			}
+			continue
+		}
+		err := c.Parents().ForEach(func(p *object.Commit) error {
+			stack = append(stack, p)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ancestors, nil
+}
+
+// newCommits returns the ancestors of head, including head, that are
+// not in seen. The ancestors of commits in seen are expected to be in seen.
+func newCommits(head *object.Commit, seen map[plumbing.Hash]bool) (map[plumbing.Hash]*object.Commit, error) {
+	commits := map[plumbing.Hash]*object.Commit{}
+	stack := []*object.Commit{head}
+	for len(stack) > 0 {
+		c := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if seen[c.Hash] || commits[c.Hash] != nil {
+			continue
+		}
+		commits[c.Hash] = c
+		err := c.Parents().ForEach(func(p *object.Commit) error {
+			stack = append(stack, p)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return commits, nil
+}
+
+// firstParentLine returns the commits that are reachable from head via
+// first parents
+func firstParentLine(head *object.Commit, commits map[plumbing.Hash]*object.Commit) map[plumbing.Hash]bool {
+	line := map[plumbing.Hash]bool{}
+	for c := commits[head.Hash]; c != nil; {
+		line[c.Hash] = true
+		if c.NumParents() == 0 {
+			break
+		}
+		c = commits[c.ParentHashes[0]]
+	}
+	return line
+}
@@ -6,7 +6,6 @@ import (
 	"io"
 	"sort"
 	"strings"
-	"time"
 
 	"github.com/blang/semver"
 	"github.com/juranki/go-semrel/semrel"
@@ -14,13 +13,15 @@ import (
 	git "gopkg.in/src-d/go-git.v4"
 	"gopkg.in/src-d/go-git.v4/plumbing"
 	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/storage"
 )
 
 // VCSData returns current version and list of unreleased changes
 //
-// Open repository at `path` and traverse parents of `HEAD` to find
+// Open repository at `path`, or at the closest parent directory that
+// contains `.git`, and traverse parents of `HEAD` to find
 // the tag that represents previous release and the commits that haven't
-// been released yet.
+// been released yet. Repository is detected from the `origin` remote.
 func VCSData(path string) (*semrel.VCSData, error) {
 	return VCSDataWithPrefix(path, "")
 }
@@ -30,11 +31,31 @@ func VCSData(path string) (*semrel.VCSData, error) {
 // The same as VCSData, but allows prefix before version, when searching earlier
 // releases. Versions without the prefix are still recognized.
 func VCSDataWithPrefix(path string, prefix string) (*semrel.VCSData, error) {
+	r, err := Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return RepositoryVCSData(r, prefix)
+}
+
+// Open opens the repository at path. When path is inside a worktree,
+// the closest parent directory that contains `.git` is opened.
+func Open(path string) (*git.Repository, error) {
+	return git.PlainOpenWithOptions(path, &git.PlainOpenOptions{DetectDotGit: true})
+}
 
-	r, err := git.PlainOpen(path)
+// StorerVCSData is VCSDataWithPrefix for a repository in storer,
+// e.g. memory.NewStorage() of an in-memory clone
+func StorerVCSData(s storage.Storer, prefix string) (*semrel.VCSData, error) {
+	r, err := git.Open(s, nil)
 	if err != nil {
 		return nil, err
 	}
+	return RepositoryVCSData(r, prefix)
+}
+
+// RepositoryVCSData is VCSDataWithPrefix for an open repository
+func RepositoryVCSData(r *git.Repository, prefix string) (*semrel.VCSData, error) {
 
 	versions, err := getVersions(r, prefix)
 	if err != nil {
@@ -46,43 +67,141 @@ func VCSDataWithPrefix(path string, prefix string) (*semrel.VCSData, error) {
 		return nil, err
 	}
 
-	t, err := getHeadTime(r)
+	head, err := getHeadCommit(r)
 	if err != nil {
 		return nil, err
 	}
-	data.Time = *t
+	data.Time = head.Author.When
+	data.SHA = head.Hash.String()
+	data.Repository = getRepositoryInfo(r)
 
 	return data, nil
 }
 
-func getHeadTime(r *git.Repository) (*time.Time, error) {
-	h, err := r.Head()
+// VCSDataForRange returns current version and list of unreleased changes
+// of target revision instead of `HEAD`
+//
+// Target and base are revisions such as branch, tag or full SHA, resolved
+// with go-git's ResolveRevision. When base is empty, the previous release is
+// searched from tags as in VCSDataWithPrefix. Otherwise the commits reachable
+// from target but not from base are unreleased, even if some of them are
+// tagged, and the current version is the latest release reachable from base.
+func VCSDataForRange(path string, prefix string, target string, base string) (*semrel.VCSData, error) {
+	r, err := Open(path)
 	if err != nil {
-		return nil, errors.Wrap(err, "get HEAD")
+		return nil, err
 	}
-	hCommit, err := r.CommitObject(h.Hash())
+	return RepositoryRangeData(r, prefix, target, base)
+}
+
+// RepositoryRangeData is VCSDataForRange for an open repository
+func RepositoryRangeData(r *git.Repository, prefix string, target string, base string) (*semrel.VCSData, error) {
+	versions, err := getVersions(r, prefix)
 	if err != nil {
 		return nil, err
 	}
 
-	return &hCommit.Author.When, nil
+	data, err := getRangeData(r, versions, target, base)
+	if err != nil {
+		return nil, err
+	}
+	data.Repository = getRepositoryInfo(r)
+
+	return data, nil
+}
+
+func getRangeData(r *git.Repository, versions map[string]semrel.Tag, target string, base string) (*semrel.VCSData, error) {
+	targetCommit, err := resolveCommit(r, target)
+	if err != nil {
+		return nil, err
+	}
+	if len(base) == 0 {
+		data, err := getCommits(targetCommit, versions, nil)
+		if err != nil {
+			return nil, err
+		}
+		data.Time = targetCommit.Author.When
+		data.SHA = targetCommit.Hash.String()
+		return data, nil
+	}
+
+	baseCommit, err := resolveCommit(r, base)
+	if err != nil {
+		return nil, err
+	}
+	baseData, err := getCommits(baseCommit, versions, nil)
+	if err != nil {
+		return nil, err
+	}
+	ancestors, err := newCommits(baseCommit, map[plumbing.Hash]bool{})
+	if err != nil {
+		return nil, err
+	}
+	released := map[plumbing.Hash]bool{}
+	for hash := range ancestors {
+		released[hash] = true
+	}
+	data, err := getCommits(targetCommit, versions, released)
+	if err != nil {
+		return nil, err
+	}
+	data.CurrentVersion = baseData.CurrentVersion
+	data.PreviousRelease = baseData.PreviousRelease
+	data.Time = targetCommit.Author.When
+	data.SHA = targetCommit.Hash.String()
+	return data, nil
+}
+
+func getHeadCommit(r *git.Repository) (*object.Commit, error) {
+	h, err := r.Head()
+	if err != nil {
+		return nil, errors.Wrap(err, "get HEAD")
+	}
+	return r.CommitObject(h.Hash())
+}
+
+// resolveCommit returns the commit that revision points to
+func resolveCommit(r *git.Repository, revision string) (*object.Commit, error) {
+	hash, err := r.ResolveRevision(plumbing.Revision(revision))
+	if err != nil {
+		return nil, errors.Wrapf(err, "resolve '%s'", revision)
+	}
+	return r.CommitObject(*hash)
 }
 
 // Search semantic versions from tags, including pre-releases
-// prefix is removed from the tag before trying to parse semantic version
-func getVersions(r *git.Repository, prefix string) (map[string]semver.Version, error) {
-	versions := make(map[string]semver.Version)
+// prefix is removed from the tag before trying to parse semantic version.
+// Tags are mapped by the SHA of tagged commit, the highest version wins.
+func getVersions(r *git.Repository, prefix string) (map[string]semrel.Tag, error) {
+	tags, err := getVersionTags(r, prefix)
+	if err != nil {
+		return nil, err
+	}
+	versions := make(map[string]semrel.Tag)
+	for _, tag := range tags {
+		prev, prevExists := versions[tag.SHA]
+		if prevExists && prev.Version.GT(tag.Version) {
+			continue
+		}
+		versions[tag.SHA] = tag
+	}
+	return versions, nil
+}
+
+// getVersionTags returns all tags that are semantic versions. Refs of
+// annotated tags are included too, with the SHA of the tag object.
+func getVersionTags(r *git.Repository, prefix string) ([]semrel.Tag, error) {
+	tags := []semrel.Tag{}
 
-	addIfSemVer := func(sha string, version string) {
-		s := strings.TrimPrefix(version, prefix)
+	addIfSemVer := func(tag semrel.Tag) {
+		s := strings.TrimPrefix(tag.Name, prefix)
 		sv, err := semver.ParseTolerant(s)
-		if err == nil {
-			prevV, prevExists := versions[sha]
-			if prevExists && prevV.GT(sv) {
-				return
-			}
-			versions[sha] = sv
+		if err != nil {
+			semrel.Logf("ignoring tag '%s': %s", tag.Name, err)
+			return
 		}
+		tag.Version = sv
+		tags = append(tags, tag)
 	}
 
 	tagRefs, err := r.Tags()
@@ -90,7 +209,7 @@ func getVersions(r *git.Repository, prefix string) (map[string]semver.Version, e
 		return nil, err
 	}
 	err = tagRefs.ForEach(func(t *plumbing.Reference) error {
-		addIfSemVer(t.Hash().String(), t.Name().Short())
+		addIfSemVer(semrel.Tag{Name: t.Name().Short(), SHA: t.Hash().String()})
 		return nil
 	})
 	if err != nil {
@@ -102,40 +221,67 @@ func getVersions(r *git.Repository, prefix string) (map[string]semver.Version, e
 		return nil, err
 	}
 	err = tagObjects.ForEach(func(t *object.Tag) error {
-		addIfSemVer(t.Target.String(), t.Name)
+		addIfSemVer(semrel.Tag{
+			Name:      t.Name,
+			SHA:       t.Target.String(),
+			Annotated: true,
+			Tagger:    t.Tagger.String(),
+			Date:      t.Tagger.When,
+			Message:   t.Message,
+		})
 		return nil
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	return versions, nil
+	return tags, nil
 }
 
-func getUnreleasedCommits(r *git.Repository, versions map[string]semver.Version) (*semrel.VCSData, error) {
-	var traverse func(*object.Commit, bool, bool) error
+func getUnreleasedCommits(r *git.Repository, versions map[string]semrel.Tag) (*semrel.VCSData, error) {
+	h, err := r.Head()
+	if err != nil {
+		return nil, errors.Wrap(err, "get HEAD")
+	}
+	hCommit, err := r.CommitObject(h.Hash())
+	if err != nil {
+		return nil, err
+	}
+	return getCommits(hCommit, versions, nil)
+}
+
+// getCommits traverses parents of head. When released is nil,
+// the latest stable release ends the traversal, otherwise commits
+// in released are the released ones.
+func getCommits(head *object.Commit, versions map[string]semrel.Tag, released map[plumbing.Hash]bool) (*semrel.VCSData, error) {
+	var traverse func(*object.Commit, bool, bool, bool) error
 	currVersion := semver.MustParse("0.0.0")
+	var currTag *semrel.Tag
 	cache := newCache()
-	traverse = func(c *object.Commit, isNew bool, isPreReleased bool) error {
-		unReleased := isNew
+	traverse = func(c *object.Commit, isNew bool, isPreReleased bool, isFirstParent bool) error {
+		unReleased := isNew && !released[c.Hash]
 		preReleased := isPreReleased
 		tag, hasTag := versions[c.Hash.String()]
 		if hasTag {
-			if len(tag.Pre) > 0 || len(tag.Build) > 0 {
+			if len(tag.Version.Pre) > 0 || len(tag.Version.Build) > 0 {
 				preReleased = true
-			} else if isNew {
+			} else if isNew && released == nil {
 				unReleased = false
-				if tag.GT(currVersion) {
-					currVersion = tag
+				if tag.Version.GT(currVersion) {
+					currVersion = tag.Version
+					if !tag.Annotated {
+						tag.Date = c.Committer.When
+					}
+					currTag = &tag
 				}
 			}
 		}
-		if !cache.add(c, unReleased, preReleased) {
+		if !cache.add(c, unReleased, preReleased, isFirstParent) {
 			return nil
 		}
 		parents := c.Parents()
 		defer parents.Close()
-		for {
+		for i := 0; ; i++ {
 			cc, err := parents.Next()
 			if err == io.EOF {
 				return nil
@@ -144,29 +290,22 @@ func getUnreleasedCommits(r *git.Repository, versions map[string]semver.Version)
 				return err
 			}
 			// fmt.Println(c.NumParents(), c.Hash, " -> ", cc.Hash)
-			traverse(cc, unReleased, preReleased)
+			traverse(cc, unReleased, preReleased, isFirstParent && i == 0)
 		}
 	}
-	h, err := r.Head()
-	if err != nil {
-		return nil, errors.Wrap(err, "get HEAD")
-	}
-	hCommit, err := r.CommitObject(h.Hash())
-	if err != nil {
-		return nil, err
-	}
-
-	err = traverse(hCommit, true, false)
+	err := traverse(head, true, false, true)
 	if err != nil {
 		return nil, err
 	}
 
 	newCommits := cache.newCommits()
 	sort.Sort(semrel.ByTime(newCommits))
+	semrel.Logf("current version %s, %d unreleased commits", currVersion, len(newCommits))
 
 	return &semrel.VCSData{
 		CurrentVersion:    currVersion,
 		UnreleasedCommits: newCommits,
+		PreviousRelease:   currTag,
 	}, nil
 }
 
@@ -195,7 +334,7 @@ func (cache *commitCache) newCommits() []semrel.Commit {
 	return rv
 }
 
-func (cache *commitCache) add(commit *object.Commit, isNew bool, isPreReleased bool) bool {
+func (cache *commitCache) add(commit *object.Commit, isNew bool, isPreReleased bool, isFirstParent bool) bool {
 	isMerge := false
 	if commit.NumParents() > 1 {
 		isMerge = true
@@ -205,11 +344,12 @@ func (cache *commitCache) add(commit *object.Commit, isNew bool, isPreReleased b
 		cache.commits[commit.Hash.String()] = &commitCacheEntry{
 			isNew: isNew,
 			commit: semrel.Commit{
-				Msg:         commit.Message,
-				SHA:         commit.Hash.String(),
-				Time:        commit.Author.When,
-				PreReleased: isPreReleased,
-				IsMerge:     isMerge,
+				Msg:           commit.Message,
+				SHA:           commit.Hash.String(),
+				Time:          commit.Author.When,
+				PreReleased:   isPreReleased,
+				IsMerge:       isMerge,
+				IsFirstParent: isFirstParent,
 			},
 		}
 		return true
@@ -217,9 +357,15 @@ func (cache *commitCache) add(commit *object.Commit, isNew bool, isPreReleased b
 	if isPreReleased {
 		entry.commit.PreReleased = true
 	}
-	if !entry.isNew || entry.isNew == isNew {
-		return false
+	changed := false
+	// parents must be revisited to propagate first parent status
+	if isFirstParent && !entry.commit.IsFirstParent {
+		entry.commit.IsFirstParent = true
+		changed = true
+	}
+	if entry.isNew && !isNew {
+		entry.isNew = false
+		changed = true
 	}
-	entry.isNew = isNew
-	return true
+	return changed
 }
@@ -0,0 +1,178 @@
+package inspectgit
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/juranki/go-semrel/ci"
+	"github.com/juranki/go-semrel/semrel"
+	"github.com/pkg/errors"
+	git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+)
+
+// Preflight describes the state of the repository before release
+type Preflight struct {
+	// Dirty lists the files that have uncommitted changes in the worktree
+	// or the index. Untracked files are ignored.
+	Dirty []string
+	// HeadTag is the version tag of HEAD, nil if HEAD is not tagged
+	HeadTag *semrel.Tag
+	// Branch that is checked out. When HEAD is detached, the branch
+	// of the CI job, if any.
+	Branch string
+	// Upstream is the tracking ref of Branch, e.g. refs/remotes/origin/master,
+	// empty when there is none. For CI jobs it is the branch in origin.
+	Upstream string
+	// Ahead is the number of commits in HEAD that are not in Upstream
+	Ahead int
+	// Behind is the number of commits in Upstream that are not in HEAD
+	Behind int
+}
+
+// Errors describes the conditions that make release unsafe, empty if
+// there are none
+func (p *Preflight) Errors() []error {
+	errs := []error{}
+	if len(p.Dirty) > 0 {
+		errs = append(errs, errors.Errorf("worktree has uncommitted changes: %s", strings.Join(p.Dirty, ", ")))
+	}
+	if p.HeadTag != nil {
+		errs = append(errs, errors.Errorf("HEAD is already tagged as %s", p.HeadTag.Name))
+	}
+	if p.Behind > 0 {
+		errs = append(errs, errors.Errorf("%s is %d commits behind %s", p.Branch, p.Behind, p.Upstream))
+	}
+	return errs
+}
+
+// Check inspects the repository at path before release. Prefix is handled
+// as in VCSDataWithPrefix.
+func Check(path string, prefix string) (*Preflight, error) {
+	r, err := Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return RepositoryCheck(r, prefix)
+}
+
+// RepositoryCheck is Check for an open repository. Worktree is not checked
+// in bare repositories.
+func RepositoryCheck(r *git.Repository, prefix string) (*Preflight, error) {
+	return checkRepository(r, prefix, ci.Detect())
+}
+
+func checkRepository(r *git.Repository, prefix string, env *ci.Env) (*Preflight, error) {
+	p := &Preflight{Dirty: []string{}}
+	head, err := r.Head()
+	if err != nil {
+		return nil, errors.Wrap(err, "get HEAD")
+	}
+
+	w, err := r.Worktree()
+	if err != nil && err != git.ErrIsBareRepository {
+		return nil, err
+	}
+	if w != nil {
+		status, err := w.Status()
+		if err != nil {
+			return nil, err
+		}
+		for file, s := range status {
+			if s.Worktree == git.Untracked && s.Staging == git.Untracked {
+				continue
+			}
+			if s.Worktree != git.Unmodified || s.Staging != git.Unmodified {
+				p.Dirty = append(p.Dirty, file)
+			}
+		}
+		sort.Strings(p.Dirty)
+	}
+
+	versions, err := getVersions(r, prefix)
+	if err != nil {
+		return nil, err
+	}
+	if tag, ok := versions[head.Hash().String()]; ok {
+		p.HeadTag = &tag
+	}
+
+	var upstream plumbing.ReferenceName
+	if head.Name().IsBranch() {
+		p.Branch = head.Name().Short()
+		if upstream, err = getUpstream(r, p.Branch); err != nil {
+			return nil, err
+		}
+	} else if p.Branch, err = ci.Branch(r, env); err != nil {
+		return nil, err
+	} else if len(p.Branch) > 0 {
+		upstream = plumbing.NewRemoteReferenceName("origin", p.Branch)
+	}
+	if len(upstream) == 0 {
+		return p, nil
+	}
+	upstreamRef, err := r.Reference(upstream, true)
+	if err == plumbing.ErrReferenceNotFound {
+		// not fetched yet
+		return p, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	p.Upstream = upstream.String()
+	p.Ahead, p.Behind, err = countDivergence(r, head.Hash(), upstreamRef.Hash())
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// getUpstream returns the tracking ref of branch from the repository
+// configuration, empty if it is not configured
+func getUpstream(r *git.Repository, branch string) (plumbing.ReferenceName, error) {
+	cfg, err := r.Config()
+	if err != nil {
+		return "", err
+	}
+	b, ok := cfg.Branches[branch]
+	if !ok || len(b.Remote) == 0 || len(b.Merge) == 0 {
+		return "", nil
+	}
+	if b.Remote == "." {
+		return b.Merge, nil
+	}
+	return plumbing.ReferenceName(fmt.Sprintf("refs/remotes/%s/%s", b.Remote, b.Merge.Short())), nil
+}
+
+// countDivergence returns the number of commits that are only reachable
+// from local and from upstream
+func countDivergence(r *git.Repository, local plumbing.Hash, upstream plumbing.Hash) (ahead int, behind int, err error) {
+	localCommit, err := r.CommitObject(local)
+	if err != nil {
+		return 0, 0, err
+	}
+	upstreamCommit, err := r.CommitObject(upstream)
+	if err != nil {
+		return 0, 0, err
+	}
+	localCommits, err := newCommits(localCommit, map[plumbing.Hash]bool{})
+	if err != nil {
+		return 0, 0, err
+	}
+	upstreamCommits, err := newCommits(upstreamCommit, map[plumbing.Hash]bool{})
+	if err != nil {
+		return 0, 0, err
+	}
+	for hash := range localCommits {
+		if upstreamCommits[hash] == nil {
+			ahead++
+		}
+	}
+	for hash := range upstreamCommits {
+		if localCommits[hash] == nil {
+			behind++
+		}
+	}
+	return ahead, behind, nil
+}
@@ -0,0 +1,63 @@
+package inspectgit
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/juranki/go-semrel/semrel"
+	"github.com/pkg/errors"
+	git "gopkg.in/src-d/go-git.v4"
+)
+
+// scp-like syntax of ssh remotes, e.g. git@github.com:owner/repo.git
+var scpRemote = regexp.MustCompile(`^(?:[^@/]+@)?([^:/]+):(.+)$`)
+
+// WebURL returns the web URL of the repository at remote, which is either
+// an URL (https, ssh or git protocol) or scp-like ssh address. Credentials,
+// ssh ports and the .git suffix are dropped.
+func WebURL(remote string) (string, error) {
+	remote = strings.TrimSpace(remote)
+	scheme, host, path := "https", "", ""
+	if match := scpRemote.FindStringSubmatch(remote); match != nil && !strings.Contains(remote, "://") {
+		host, path = match[1], match[2]
+	} else {
+		u, err := url.Parse(remote)
+		if err != nil {
+			return "", errors.Wrapf(err, "parse remote '%s'", remote)
+		}
+		switch u.Scheme {
+		case "http", "https":
+			scheme, host = u.Scheme, u.Host
+		case "ssh", "git", "git+ssh", "ssh+git":
+			host = u.Hostname()
+		default:
+			return "", errors.Errorf("unsupported remote '%s'", remote)
+		}
+		path = u.Path
+	}
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	if len(host) == 0 || len(path) == 0 {
+		return "", errors.Errorf("unsupported remote '%s'", remote)
+	}
+	return scheme + "://" + host + "/" + path, nil
+}
+
+// getRepositoryInfo returns RepositoryInfo of the origin remote,
+// or nil if there is no such remote
+func getRepositoryInfo(r *git.Repository) *semrel.RepositoryInfo {
+	remote, err := r.Remote("origin")
+	if err != nil {
+		return nil
+	}
+	urls := remote.Config().URLs
+	if len(urls) == 0 {
+		return nil
+	}
+	webURL, err := WebURL(urls[0])
+	if err != nil {
+		semrel.Logf("ignoring origin: %s", err)
+		return nil
+	}
+	return semrel.NewRepositoryInfo(webURL, semrel.UnknownFlavor)
+}
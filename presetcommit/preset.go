@@ -0,0 +1,227 @@
+// Package presetcommit analyzes commit messages of conventions supported
+// by conventional-changelog
+//
+// https://github.com/conventional-changelog/conventional-changelog
+//
+// Each convention is a Preset, that describes the head line with a regular
+// expression and maps the types to bump levels.
+package presetcommit
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/juranki/go-semrel/semrel"
+)
+
+var (
+	// ESLint convention, e.g. `Fix: crash (fixes #1)`
+	//
+	// https://eslint.org/docs/developer-guide/contributing/pull-requests#step-2-make-your-changes
+	ESLint = &Preset{
+		Name: "eslint",
+		Head: regexp.MustCompile(`^(?P<type>[A-Z][a-z]*):\s*(?P<subject>.*)$`),
+		Types: map[string]semrel.BumpLevel{
+			"Breaking": semrel.BumpMajor,
+			"New":      semrel.BumpMinor,
+			"Update":   semrel.BumpMinor,
+			"Fix":      semrel.BumpPatch,
+			"Upgrade":  semrel.BumpPatch,
+			"Docs":     semrel.NoBump,
+			"Build":    semrel.NoBump,
+			"Chore":    semrel.NoBump,
+		},
+	}
+	// Ember convention, e.g. `[BUGFIX beta] fix crash`, the scope is
+	// the release channel or the feature flag
+	//
+	// https://github.com/emberjs/ember.js/blob/master/CONTRIBUTING.md#commit-tagging
+	Ember = &Preset{
+		Name: "ember",
+		Head: regexp.MustCompile(`^\[(?P<type>[A-Z]+)(?:\s+(?P<scope>[^\]]*))?\]\s*(?P<subject>.*)$`),
+		Types: map[string]semrel.BumpLevel{
+			"FEATURE":  semrel.BumpMinor,
+			"BUGFIX":   semrel.BumpPatch,
+			"SECURITY": semrel.BumpPatch,
+			"DOC":      semrel.NoBump,
+			"CLEANUP":  semrel.NoBump,
+		},
+	}
+	// JQuery convention, e.g. `Ajax: fix crash`. The type and scope are
+	// the component, changes to other components than build, docs and tests
+	// are fixes. Breaking changes are described in the body.
+	//
+	// https://contribute.jquery.org/commits-and-pull-requests/#commit-guidelines
+	JQuery = &Preset{
+		Name: "jquery",
+		Head: regexp.MustCompile(`^(?P<scope>(?P<type>[\w-]+)):\s*(?P<subject>.*)$`),
+		Types: map[string]semrel.BumpLevel{
+			"Build": semrel.NoBump,
+			"Docs":  semrel.NoBump,
+			"Tests": semrel.NoBump,
+		},
+		OtherTypes: semrel.BumpPatch,
+		AnyType:    true,
+		Breaking:   regexp.MustCompile(`(?ms)^BREAKING CHANGE:?\s+(.*)`),
+	}
+	// Atom convention, e.g. `:bug: fix crash`
+	//
+	// https://github.com/atom/atom/blob/master/CONTRIBUTING.md#git-commit-messages
+	Atom = &Preset{
+		Name: "atom",
+		Head: regexp.MustCompile(`^(?P<type>:[a-z_-]+:)\s*(?P<subject>.*)$`),
+		Types: map[string]semrel.BumpLevel{
+			":bug:":               semrel.BumpPatch,
+			":racehorse:":         semrel.BumpPatch,
+			":penguin:":           semrel.BumpPatch,
+			":apple:":             semrel.BumpPatch,
+			":checkered_flag:":    semrel.BumpPatch,
+			":non-potable_water:": semrel.BumpPatch,
+			":lock:":              semrel.BumpPatch,
+			":arrow_up:":          semrel.BumpPatch,
+			":arrow_down:":        semrel.BumpPatch,
+			":art:":               semrel.NoBump,
+			":memo:":              semrel.NoBump,
+			":fire:":              semrel.NoBump,
+			":green_heart:":       semrel.NoBump,
+			":white_check_mark:":  semrel.NoBump,
+			":shirt:":             semrel.NoBump,
+		},
+	}
+)
+
+// Preset describes a commit convention
+type Preset struct {
+	Name string
+	// Head matches the head line of the message. Submatches named `type`,
+	// `scope` and `subject` are used.
+	Head *regexp.Regexp
+	// Types maps the types to bump levels
+	Types map[string]semrel.BumpLevel
+	// AnyType accepts types not in Types, with bump level OtherTypes
+	AnyType    bool
+	OtherTypes semrel.BumpLevel
+	// Breaking matches the description of a breaking change in the
+	// message, first submatch is the description
+	Breaking *regexp.Regexp
+}
+
+// Analyzer is a semrel.Analyzer instance that parses commits
+// according to a preset
+type Analyzer struct {
+	preset *Preset
+}
+
+// New initializes Analyzer with preset
+func New(preset *Preset) *Analyzer {
+	return &Analyzer{
+		preset: preset,
+	}
+}
+
+// Lint checks if message head matches the preset and the type is known
+func (analyzer *Analyzer) Lint(message string) []error {
+	c := analyzer.preset.parse(message)
+	if c == nil {
+		return []error{errors.New("invalid message head")}
+	}
+	if _, ok := analyzer.preset.bumpLevel(c.Type); !ok {
+		return []error{fmt.Errorf("invalid type '%s'", c.Type)}
+	}
+	return []error{}
+}
+
+// Analyze implements semrel.Analyzer interface for presetcommit.Analyzer.
+// Commits that don't match the preset or have unknown type are ignored.
+func (analyzer *Analyzer) Analyze(commit *semrel.Commit) ([]semrel.Change, error) {
+	changes := []semrel.Change{}
+	c := analyzer.preset.parse(commit.Msg)
+	if c == nil {
+		return changes, nil
+	}
+	level, ok := analyzer.preset.bumpLevel(c.Type)
+	if !ok {
+		return changes, nil
+	}
+	c.bumpLevel = level
+	if len(c.BreakingMessage) > 0 {
+		c.bumpLevel = semrel.BumpMajor
+	}
+	c.Hash = commit.SHA
+	c.commit = *commit
+	return append(changes, c), nil
+}
+
+func (preset *Preset) bumpLevel(commitType string) (semrel.BumpLevel, bool) {
+	if level, ok := preset.Types[commitType]; ok {
+		return level, true
+	}
+	return preset.OtherTypes, preset.AnyType
+}
+
+// parse returns the change described by message, or nil if the head line
+// doesn't match
+func (preset *Preset) parse(message string) *Change {
+	t := strings.Replace(message, "\r", "", -1)
+	head := strings.TrimSpace(strings.SplitN(t, "\n", 2)[0])
+	match := preset.Head.FindStringSubmatch(head)
+	if match == nil {
+		return nil
+	}
+	c := &Change{}
+	for i, name := range preset.Head.SubexpNames() {
+		value := strings.TrimSpace(match[i])
+		switch name {
+		case "type":
+			c.Type = value
+		case "scope":
+			c.Scope = value
+		case "subject":
+			c.Subject = value
+		}
+	}
+	if preset.Breaking != nil {
+		if match := preset.Breaking.FindStringSubmatch(t); len(match) > 1 {
+			c.BreakingMessage = strings.TrimSpace(match[1])
+		}
+	}
+	return c
+}
+
+// Change captures commit message analysis
+type Change struct {
+	Type            string
+	Scope           string
+	Subject         string
+	BreakingMessage string
+	Hash            string
+	bumpLevel       semrel.BumpLevel
+	commit          semrel.Commit
+}
+
+// Category implements semrel.Change interface
+func (c *Change) Category() string {
+	return semrel.DefaultCategory(c.bumpLevel)
+}
+
+// BumpLevel implements semrel.Change interface
+func (c *Change) BumpLevel() semrel.BumpLevel {
+	return c.bumpLevel
+}
+
+// PreReleased implements semrel.Change interface
+func (c *Change) PreReleased() bool {
+	return c.commit.PreReleased
+}
+
+// Describe implements semrel.Describer interface
+func (c *Change) Describe() semrel.Description {
+	return semrel.Description{
+		Scope:    c.Scope,
+		Subject:  c.Subject,
+		Breaking: c.BreakingMessage,
+		SHA:      c.Hash,
+	}
+}
@@ -0,0 +1,90 @@
+package presetcommit
+
+import (
+	"testing"
+
+	"github.com/juranki/go-semrel/semrel"
+)
+
+type presetCase struct {
+	msg      string
+	category string
+	scope    string
+	subject  string
+	lint     string
+}
+
+func checkPreset(t *testing.T, preset *Preset, cases []presetCase) {
+	t.Helper()
+	a := New(preset)
+	for _, c := range cases {
+		changes, err := a.Analyze(&semrel.Commit{Msg: c.msg, SHA: "abc"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(c.category) == 0 {
+			if len(changes) > 0 {
+				t.Errorf("'%s': got %+v, want no changes", c.msg, changes[0])
+			}
+		} else if len(changes) != 1 {
+			t.Errorf("'%s': got %d changes, want 1", c.msg, len(changes))
+		} else {
+			d := changes[0].(semrel.Describer).Describe()
+			if changes[0].Category() != c.category || d.Scope != c.scope || d.Subject != c.subject {
+				t.Errorf("'%s': got %s/%s/%s, want %s/%s/%s", c.msg,
+					changes[0].Category(), d.Scope, d.Subject, c.category, c.scope, c.subject)
+			}
+		}
+		errs := a.Lint(c.msg)
+		lint := ""
+		if len(errs) > 0 {
+			lint = errs[0].Error()
+		}
+		if lint != c.lint {
+			t.Errorf("'%s': got lint '%s', want '%s'", c.msg, lint, c.lint)
+		}
+	}
+}
+
+func TestESLint(t *testing.T) {
+	checkPreset(t, ESLint, []presetCase{
+		{"Fix: crash on start (fixes #1)", "fix", "", "crash on start (fixes #1)", ""},
+		{"New: add rule", "feature", "", "add rule", ""},
+		{"Update: support option", "feature", "", "support option", ""},
+		{"Breaking: drop node 6", "breaking", "", "drop node 6", ""},
+		{"Docs: fix typo", "other", "", "fix typo", ""},
+		{"Feat: add rule", "", "", "", "invalid type 'Feat'"},
+		{"fix: crash", "", "", "", "invalid message head"},
+	})
+}
+
+func TestEmber(t *testing.T) {
+	checkPreset(t, Ember, []presetCase{
+		{"[BUGFIX beta] fix crash", "fix", "beta", "fix crash", ""},
+		{"[FEATURE ember-routing] add router", "feature", "ember-routing", "add router", ""},
+		{"[SECURITY CVE-2019-1] escape input", "fix", "CVE-2019-1", "escape input", ""},
+		{"[DOC release] update guide", "other", "release", "update guide", ""},
+		{"[CLEANUP] remove dead code", "other", "", "remove dead code", ""},
+		{"[FOO beta] x", "", "", "", "invalid type 'FOO'"},
+		{"fix crash", "", "", "", "invalid message head"},
+	})
+}
+
+func TestJQuery(t *testing.T) {
+	checkPreset(t, JQuery, []presetCase{
+		{"Ajax: fix crash\n\nFixes #1", "fix", "Ajax", "fix crash", ""},
+		{"Core: remove jQuery.isArray\n\nBREAKING CHANGE: use Array.isArray", "breaking", "Core", "remove jQuery.isArray", ""},
+		{"Docs: update readme", "other", "Docs", "update readme", ""},
+		{"fix crash", "", "", "", "invalid message head"},
+	})
+}
+
+func TestAtom(t *testing.T) {
+	checkPreset(t, Atom, []presetCase{
+		{":bug: fix crash", "fix", "", "fix crash", ""},
+		{":racehorse: faster startup", "fix", "", "faster startup", ""},
+		{":memo: update docs", "other", "", "update docs", ""},
+		{":sparkles: new thing", "", "", "", "invalid type ':sparkles:'"},
+		{"fix crash", "", "", "", "invalid message head"},
+	})
+}
@@ -0,0 +1,153 @@
+package publisher
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/juranki/go-semrel/semrel"
+)
+
+// APIError is returned when the service responds with an error status
+type APIError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s %s: %d %s", e.Method, e.URL, e.StatusCode, e.Message)
+}
+
+// IsNotFound reports whether err is an APIError with status 404
+func IsNotFound(err error) bool {
+	e, ok := err.(*APIError)
+	return ok && e.StatusCode == http.StatusNotFound
+}
+
+// apiClient makes JSON requests to a REST API
+type apiClient struct {
+	baseURL string
+	client  *http.Client
+	header  http.Header
+	// requests are retried when rate limited, unless the wait is too long
+	maxRetries int
+	maxWait    time.Duration
+	sleep      func(time.Duration)
+	now        func() time.Time
+}
+
+func newAPIClient(baseURL string, client *http.Client, header http.Header) *apiClient {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &apiClient{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		client:     client,
+		header:     header,
+		maxRetries: 3,
+		maxWait:    time.Minute,
+		sleep:      time.Sleep,
+		now:        time.Now,
+	}
+}
+
+// do sends in as JSON and decodes the response to out, when they are not nil
+func (c *apiClient) do(method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, c.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	return c.send(req, out)
+}
+
+// send sends req with authentication headers and decodes the response to out
+func (c *apiClient) send(req *http.Request, out interface{}) error {
+	for k, v := range c.header {
+		req.Header[k] = v
+	}
+	var res *http.Response
+	for attempt := 0; ; attempt++ {
+		var err error
+		res, err = c.client.Do(req)
+		if err != nil {
+			return err
+		}
+		wait, limited := c.rateLimited(res)
+		if !limited || attempt >= c.maxRetries || wait > c.maxWait || req.GetBody == nil && req.Body != nil {
+			break
+		}
+		res.Body.Close()
+		semrel.Logf("%s %s: rate limited, retrying in %s", req.Method, req.URL, wait)
+		c.sleep(wait)
+		if req.GetBody != nil {
+			if req.Body, err = req.GetBody(); err != nil {
+				return err
+			}
+		}
+	}
+	defer res.Body.Close()
+	data, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	if res.StatusCode >= 300 {
+		return &APIError{
+			Method:     req.Method,
+			URL:        req.URL.String(),
+			StatusCode: res.StatusCode,
+			Message:    strings.TrimSpace(string(data)),
+		}
+	}
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
+
+// rateLimited reports whether res is a rate limit response, and how long
+// to wait before retrying
+func (c *apiClient) rateLimited(res *http.Response) (time.Duration, bool) {
+	if res.StatusCode != http.StatusTooManyRequests && res.StatusCode != http.StatusForbidden {
+		return 0, false
+	}
+	if s := res.Header.Get("Retry-After"); len(s) > 0 {
+		if seconds, err := strconv.Atoi(s); err == nil {
+			return time.Duration(seconds) * time.Second, true
+		}
+	}
+	if res.Header.Get("X-RateLimit-Remaining") == "0" {
+		reset, err := strconv.ParseInt(res.Header.Get("X-RateLimit-Reset"), 10, 64)
+		if err != nil {
+			return 0, false
+		}
+		wait := time.Unix(reset, 0).Sub(c.now()) + time.Second
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	if res.StatusCode == http.StatusTooManyRequests {
+		return time.Second, true
+	}
+	return 0, false
+}
@@ -0,0 +1,154 @@
+package publisher
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// GiteaConfig configures Gitea publisher
+type GiteaConfig struct {
+	// BaseURL of the API, e.g. https://codeberg.org/api/v1
+	BaseURL string
+	Token   string
+	Owner   string
+	Repo    string
+	// Client used for requests, http.DefaultClient when nil
+	Client *http.Client
+}
+
+// Gitea publishes releases using Gitea API, Forgejo is compatible.
+// The tag is created if it doesn't exist, with notes as its message.
+// Assets are uploaded from local files as attachments, they must have Path.
+type Gitea struct {
+	config GiteaConfig
+	api    *apiClient
+}
+
+// NewGitea initializes Gitea publisher
+func NewGitea(config GiteaConfig) *Gitea {
+	return &Gitea{
+		config: config,
+		api: newAPIClient(config.BaseURL, config.Client, http.Header{
+			"Authorization": []string{"token " + config.Token},
+		}),
+	}
+}
+
+type giteaTag struct {
+	TagName string `json:"tag_name"`
+	Target  string `json:"target,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type giteaRelease struct {
+	TagName    string `json:"tag_name"`
+	Target     string `json:"target_commitish,omitempty"`
+	Name       string `json:"name"`
+	Body       string `json:"body"`
+	Prerelease bool   `json:"prerelease"`
+}
+
+type giteaAttachment struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type giteaReleaseResponse struct {
+	ID      int               `json:"id"`
+	HTMLURL string            `json:"html_url"`
+	Assets  []giteaAttachment `json:"assets"`
+}
+
+// Publish implements Publisher interface. Existing attachments with the
+// same name are replaced.
+func (g *Gitea) Publish(r *Release) (string, error) {
+	for _, a := range r.Assets {
+		if len(a.Path) == 0 {
+			return "", errors.Errorf("gitea: asset '%s' has no file", a.Name)
+		}
+	}
+	repoPath := "/repos/" + url.PathEscape(g.config.Owner) + "/" + url.PathEscape(g.config.Repo)
+	err := g.api.do("GET", repoPath+"/tags/"+url.PathEscape(r.Tag), nil, nil)
+	if IsNotFound(err) {
+		err = g.api.do("POST", repoPath+"/tags", &giteaTag{
+			TagName: r.Tag,
+			Target:  r.Target,
+			Message: r.Notes,
+		}, nil)
+	}
+	if err != nil {
+		return "", err
+	}
+	in := giteaRelease{
+		TagName:    r.Tag,
+		Target:     r.Target,
+		Name:       r.Name,
+		Body:       r.Notes,
+		Prerelease: r.Prerelease,
+	}
+	out := giteaReleaseResponse{}
+	err = g.api.do("GET", repoPath+"/releases/tags/"+url.PathEscape(r.Tag), nil, &out)
+	switch {
+	case IsNotFound(err):
+		err = g.api.do("POST", repoPath+"/releases", &in, &out)
+	case err == nil:
+		err = g.api.do("PATCH", repoPath+"/releases/"+strconv.Itoa(out.ID), &in, &out)
+	}
+	if err != nil {
+		return "", err
+	}
+	releasePath := repoPath + "/releases/" + strconv.Itoa(out.ID)
+	for _, a := range r.Assets {
+		if err := g.upload(releasePath, &out, a); err != nil {
+			return "", err
+		}
+	}
+	return out.HTMLURL, nil
+}
+
+func (g *Gitea) upload(releasePath string, release *giteaReleaseResponse, a Asset) error {
+	name := a.Name
+	if len(name) == 0 {
+		name = filepath.Base(a.Path)
+	}
+	f, err := os.Open(a.Path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	body := &bytes.Buffer{}
+	form := multipart.NewWriter(body)
+	part, err := form.CreateFormFile("attachment", name)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(part, f); err != nil {
+		return err
+	}
+	if err := form.Close(); err != nil {
+		return err
+	}
+	for _, existing := range release.Assets {
+		if existing.Name == name {
+			err := g.api.do("DELETE", releasePath+"/assets/"+strconv.Itoa(existing.ID), nil, nil)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	req, err := http.NewRequest("POST", g.api.baseURL+releasePath+"/assets?name="+url.QueryEscape(name), body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("Accept", "application/json")
+	return g.api.send(req, nil)
+}
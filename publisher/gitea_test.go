@@ -0,0 +1,185 @@
+package publisher
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// giteaStandIn implements the tag and release endpoints of Gitea API
+type giteaStandIn struct {
+	tags        map[string]giteaTag
+	releases    map[int]*giteaRelease
+	attachments map[int][]giteaAttachment
+	uploads     map[string]string
+	requests    []string
+	nextID      int
+}
+
+func newGiteaStandIn() (*giteaStandIn, *httptest.Server) {
+	s := &giteaStandIn{
+		tags:        map[string]giteaTag{},
+		releases:    map[int]*giteaRelease{},
+		attachments: map[int][]giteaAttachment{},
+		uploads:     map[string]string{},
+	}
+	const prefix = "/api/v1/repos/o/r"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.EscapedPath()
+		s.requests = append(s.requests, r.Method+" "+path)
+		if r.Header.Get("Authorization") != "token secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		respond := func(id int) {
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"id":       id,
+				"html_url": "https://forgejo.example.com/o/r/releases/tag/" + s.releases[id].TagName,
+				"assets":   s.attachments[id],
+			})
+		}
+		parts := strings.Split(strings.TrimPrefix(path, prefix+"/"), "/")
+		switch {
+		case r.Method == "GET" && parts[0] == "tags" && len(parts) == 2:
+			if _, ok := s.tags[parts[1]]; !ok {
+				w.WriteHeader(http.StatusNotFound)
+			}
+		case r.Method == "POST" && path == prefix+"/tags":
+			tag := giteaTag{}
+			json.NewDecoder(r.Body).Decode(&tag)
+			s.tags[tag.TagName] = tag
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == "GET" && len(parts) == 3 && parts[1] == "tags":
+			for id, rel := range s.releases {
+				if rel.TagName == parts[2] {
+					respond(id)
+					return
+				}
+			}
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == "POST" && path == prefix+"/releases":
+			rel := &giteaRelease{}
+			json.NewDecoder(r.Body).Decode(rel)
+			s.nextID++
+			s.releases[s.nextID] = rel
+			w.WriteHeader(http.StatusCreated)
+			respond(s.nextID)
+		case r.Method == "PATCH" && len(parts) == 2:
+			id, _ := strconv.Atoi(parts[1])
+			json.NewDecoder(r.Body).Decode(s.releases[id])
+			respond(id)
+		case r.Method == "DELETE" && len(parts) == 4:
+			id, _ := strconv.Atoi(parts[1])
+			attachmentID, _ := strconv.Atoi(parts[3])
+			kept := []giteaAttachment{}
+			for _, a := range s.attachments[id] {
+				if a.ID != attachmentID {
+					kept = append(kept, a)
+				}
+			}
+			s.attachments[id] = kept
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == "POST" && len(parts) == 3 && parts[2] == "assets":
+			id, _ := strconv.Atoi(parts[1])
+			f, header, err := r.FormFile("attachment")
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			data, _ := ioutil.ReadAll(f)
+			name := r.URL.Query().Get("name")
+			s.nextID++
+			s.attachments[id] = append(s.attachments[id], giteaAttachment{ID: s.nextID, Name: name})
+			s.uploads[name] = header.Filename + " " + string(data)
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	return s, server
+}
+
+func TestGitea_Publish(t *testing.T) {
+	s, server := newGiteaStandIn()
+	defer server.Close()
+	dir, err := ioutil.TempDir("", "publisher")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ioutil.WriteFile(filepath.Join(dir, "app.tar.gz"), []byte("v1"), 0644)
+
+	g := NewGitea(GiteaConfig{
+		BaseURL: server.URL + "/api/v1/",
+		Token:   "secret",
+		Owner:   "o",
+		Repo:    "r",
+	})
+	r := &Release{
+		Tag:        "v1.0.0-rc.1",
+		Name:       "v1.0.0-rc.1",
+		Notes:      "notes",
+		Prerelease: true,
+		Target:     "main",
+		Assets:     []Asset{{Name: "app-linux.tar.gz", Path: filepath.Join(dir, "app.tar.gz")}},
+	}
+	url, err := g.Publish(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url != "https://forgejo.example.com/o/r/releases/tag/v1.0.0-rc.1" {
+		t.Errorf("got url %s", url)
+	}
+	wantTag := giteaTag{TagName: "v1.0.0-rc.1", Target: "main", Message: "notes"}
+	if s.tags["v1.0.0-rc.1"] != wantTag {
+		t.Errorf("got tag %+v", s.tags)
+	}
+	want := giteaRelease{TagName: "v1.0.0-rc.1", Target: "main", Name: "v1.0.0-rc.1", Body: "notes", Prerelease: true}
+	if !reflect.DeepEqual(*s.releases[1], want) {
+		t.Errorf("got %+v, want %+v", *s.releases[1], want)
+	}
+	if s.uploads["app-linux.tar.gz"] != "app-linux.tar.gz v1" {
+		t.Errorf("got upload '%s'", s.uploads["app-linux.tar.gz"])
+	}
+
+	ioutil.WriteFile(filepath.Join(dir, "app.tar.gz"), []byte("v2"), 0644)
+	r.Notes = "updated"
+	s.requests = nil
+	if _, err := g.Publish(r); err != nil {
+		t.Fatal(err)
+	}
+	wantRequests := []string{
+		"GET /api/v1/repos/o/r/tags/v1.0.0-rc.1",
+		"GET /api/v1/repos/o/r/releases/tags/v1.0.0-rc.1",
+		"PATCH /api/v1/repos/o/r/releases/1",
+		"DELETE /api/v1/repos/o/r/releases/1/assets/2",
+		"POST /api/v1/repos/o/r/releases/1/assets",
+	}
+	if !reflect.DeepEqual(s.requests, wantRequests) {
+		t.Errorf("got requests\n%s\nwant\n%s", strings.Join(s.requests, "\n"), strings.Join(wantRequests, "\n"))
+	}
+	if s.releases[1].Body != "updated" || s.uploads["app-linux.tar.gz"] != "app-linux.tar.gz v2" || len(s.attachments[1]) != 1 {
+		t.Errorf("got %+v, %+v, %+v", s.releases[1], s.uploads, s.attachments[1])
+	}
+}
+
+func TestGitea_Errors(t *testing.T) {
+	_, server := newGiteaStandIn()
+	defer server.Close()
+	g := NewGitea(GiteaConfig{BaseURL: server.URL + "/api/v1", Token: "wrong", Owner: "o", Repo: "r"})
+	_, err := g.Publish(&Release{Tag: "v1.0.0"})
+	if e, ok := err.(*APIError); !ok || e.StatusCode != http.StatusUnauthorized {
+		t.Errorf("got %v, want unauthorized", err)
+	}
+	_, err = g.Publish(&Release{Tag: "v1.0.0", Assets: []Asset{{Name: "link", URL: "https://example.com"}}})
+	if err == nil || err.Error() != "gitea: asset 'link' has no file" {
+		t.Errorf("got %v", err)
+	}
+}
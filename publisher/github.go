@@ -0,0 +1,147 @@
+package publisher
+
+import (
+	"bytes"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// GitHubConfig configures GitHub publisher
+type GitHubConfig struct {
+	// BaseURL of the API, https://api.github.com or
+	// https://github.example.com/api/v3 for GitHub Enterprise
+	BaseURL string
+	Token   string
+	Owner   string
+	Repo    string
+	// DraftPrereleases creates pre-releases as drafts instead of
+	// marking them as pre-releases
+	DraftPrereleases bool
+	// MaxRetries of rate limited requests, 3 when zero, negative disables retries
+	MaxRetries int
+	// Client used for requests, http.DefaultClient when nil
+	Client *http.Client
+}
+
+// GitHub publishes releases using GitHub REST API v3. GitHub creates
+// the tag on Release.Target when it doesn't exist. Assets are uploaded
+// from local files, they must have Path.
+type GitHub struct {
+	config GitHubConfig
+	api    *apiClient
+}
+
+// NewGitHub initializes GitHub publisher
+func NewGitHub(config GitHubConfig) *GitHub {
+	api := newAPIClient(config.BaseURL, config.Client, http.Header{
+		"Authorization": []string{"token " + config.Token},
+	})
+	if config.MaxRetries < 0 {
+		api.maxRetries = 0
+	} else if config.MaxRetries > 0 {
+		api.maxRetries = config.MaxRetries
+	}
+	return &GitHub{
+		config: config,
+		api:    api,
+	}
+}
+
+type githubRelease struct {
+	TagName    string `json:"tag_name"`
+	Target     string `json:"target_commitish,omitempty"`
+	Name       string `json:"name"`
+	Body       string `json:"body"`
+	Draft      bool   `json:"draft"`
+	Prerelease bool   `json:"prerelease"`
+}
+
+type githubAsset struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type githubReleaseResponse struct {
+	ID        int           `json:"id"`
+	HTMLURL   string        `json:"html_url"`
+	UploadURL string        `json:"upload_url"`
+	Assets    []githubAsset `json:"assets"`
+}
+
+// Publish implements Publisher interface. Existing assets with the same
+// name are replaced.
+func (g *GitHub) Publish(r *Release) (string, error) {
+	for _, a := range r.Assets {
+		if len(a.Path) == 0 {
+			return "", errors.Errorf("github: asset '%s' has no file", a.Name)
+		}
+	}
+	repoPath := "/repos/" + url.PathEscape(g.config.Owner) + "/" + url.PathEscape(g.config.Repo)
+	in := githubRelease{
+		TagName:    r.Tag,
+		Target:     r.Target,
+		Name:       r.Name,
+		Body:       r.Notes,
+		Draft:      r.Prerelease && g.config.DraftPrereleases,
+		Prerelease: r.Prerelease && !g.config.DraftPrereleases,
+	}
+	out := githubReleaseResponse{}
+	err := g.api.do("GET", repoPath+"/releases/tags/"+url.PathEscape(r.Tag), nil, &out)
+	switch {
+	case IsNotFound(err):
+		err = g.api.do("POST", repoPath+"/releases", &in, &out)
+	case err == nil:
+		err = g.api.do("PATCH", repoPath+"/releases/"+strconv.Itoa(out.ID), &in, &out)
+	}
+	if err != nil {
+		return "", err
+	}
+	for _, a := range r.Assets {
+		if err := g.upload(repoPath, &out, a); err != nil {
+			return "", err
+		}
+	}
+	return out.HTMLURL, nil
+}
+
+func (g *GitHub) upload(repoPath string, release *githubReleaseResponse, a Asset) error {
+	name := a.Name
+	if len(name) == 0 {
+		name = filepath.Base(a.Path)
+	}
+	data, err := ioutil.ReadFile(a.Path)
+	if err != nil {
+		return err
+	}
+	for _, existing := range release.Assets {
+		if existing.Name == name {
+			err := g.api.do("DELETE", repoPath+"/releases/assets/"+strconv.Itoa(existing.ID), nil, nil)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	// upload_url is a URI template, e.g. https://uploads.github.com/repos/o/r/releases/1/assets{?name,label}
+	uploadURL := release.UploadURL
+	if i := strings.Index(uploadURL, "{"); i >= 0 {
+		uploadURL = uploadURL[:i]
+	}
+	req, err := http.NewRequest("POST", uploadURL+"?name="+url.QueryEscape(name), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	contentType := mime.TypeByExtension(filepath.Ext(name))
+	if len(contentType) == 0 {
+		contentType = "application/octet-stream"
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Accept", "application/json")
+	return g.api.send(req, nil)
+}
@@ -0,0 +1,221 @@
+package publisher
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// githubStandIn implements the release endpoints of GitHub API
+type githubStandIn struct {
+	releases  map[int]*githubRelease
+	assets    map[int][]githubAsset
+	uploads   map[string]string
+	requests  []string
+	rateLimit int
+	nextID    int
+}
+
+func newGitHubStandIn() (*githubStandIn, *httptest.Server) {
+	s := &githubStandIn{
+		releases: map[int]*githubRelease{},
+		assets:   map[int][]githubAsset{},
+		uploads:  map[string]string{},
+	}
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.EscapedPath()
+		s.requests = append(s.requests, r.Method+" "+path)
+		if r.Header.Get("Authorization") != "token secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if s.rateLimit > 0 {
+			s.rateLimit--
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", "1000005")
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		respond := func(id int) {
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"id":         id,
+				"html_url":   fmt.Sprintf("https://github.example.com/o/r/releases/tag/%s", s.releases[id].TagName),
+				"upload_url": fmt.Sprintf("%s/api/uploads/repos/o/r/releases/%d/assets{?name,label}", server.URL, id),
+				"assets":     s.assets[id],
+			})
+		}
+		const prefix = "/api/v3/repos/o/r/releases"
+		switch {
+		case r.Method == "GET" && strings.HasPrefix(path, prefix+"/tags/"):
+			tag := strings.TrimPrefix(path, prefix+"/tags/")
+			for id, rel := range s.releases {
+				if url := rel.TagName; strings.Replace(url, "/", "%2F", -1) == tag {
+					respond(id)
+					return
+				}
+			}
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == "POST" && path == prefix:
+			rel := &githubRelease{}
+			json.Unmarshal(body, rel)
+			s.nextID++
+			s.releases[s.nextID] = rel
+			w.WriteHeader(http.StatusCreated)
+			respond(s.nextID)
+		case r.Method == "PATCH" && strings.HasPrefix(path, prefix+"/"):
+			id, _ := strconv.Atoi(strings.TrimPrefix(path, prefix+"/"))
+			json.Unmarshal(body, s.releases[id])
+			respond(id)
+		case r.Method == "DELETE" && strings.HasPrefix(path, prefix+"/assets/"):
+			id, _ := strconv.Atoi(strings.TrimPrefix(path, prefix+"/assets/"))
+			for rid, assets := range s.assets {
+				kept := []githubAsset{}
+				for _, a := range assets {
+					if a.ID != id {
+						kept = append(kept, a)
+					}
+				}
+				s.assets[rid] = kept
+			}
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == "POST" && strings.HasPrefix(path, "/api/uploads/repos/o/r/releases/"):
+			id, _ := strconv.Atoi(strings.Split(path, "/")[7])
+			name := r.URL.Query().Get("name")
+			s.nextID++
+			s.assets[id] = append(s.assets[id], githubAsset{ID: s.nextID, Name: name})
+			s.uploads[name] = r.Header.Get("Content-Type") + " " + string(body)
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	return s, server
+}
+
+func TestGitHub_Publish(t *testing.T) {
+	s, server := newGitHubStandIn()
+	defer server.Close()
+	dir, err := ioutil.TempDir("", "publisher")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ioutil.WriteFile(filepath.Join(dir, "app.zip"), []byte("v1"), 0644)
+
+	g := NewGitHub(GitHubConfig{
+		BaseURL: server.URL + "/api/v3",
+		Token:   "secret",
+		Owner:   "o",
+		Repo:    "r",
+	})
+	r := &Release{
+		Tag:        "v1.0.0-rc.1",
+		Name:       "v1.0.0-rc.1",
+		Notes:      "notes",
+		Prerelease: true,
+		Assets:     []Asset{{Path: filepath.Join(dir, "app.zip")}},
+		Target:     "abc",
+	}
+	url, err := g.Publish(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url != "https://github.example.com/o/r/releases/tag/v1.0.0-rc.1" {
+		t.Errorf("got url %s", url)
+	}
+	want := githubRelease{TagName: "v1.0.0-rc.1", Target: "abc", Name: "v1.0.0-rc.1", Body: "notes", Prerelease: true}
+	if !reflect.DeepEqual(*s.releases[1], want) {
+		t.Errorf("got %+v, want %+v", *s.releases[1], want)
+	}
+	if s.uploads["app.zip"] != "application/zip v1" {
+		t.Errorf("got upload '%s'", s.uploads["app.zip"])
+	}
+
+	ioutil.WriteFile(filepath.Join(dir, "app.zip"), []byte("v2"), 0644)
+	r.Notes = "updated"
+	s.requests = nil
+	if _, err := g.Publish(r); err != nil {
+		t.Fatal(err)
+	}
+	wantRequests := []string{
+		"GET /api/v3/repos/o/r/releases/tags/v1.0.0-rc.1",
+		"PATCH /api/v3/repos/o/r/releases/1",
+		"DELETE /api/v3/repos/o/r/releases/assets/2",
+		"POST /api/uploads/repos/o/r/releases/1/assets",
+	}
+	if !reflect.DeepEqual(s.requests, wantRequests) {
+		t.Errorf("got requests\n%s\nwant\n%s", strings.Join(s.requests, "\n"), strings.Join(wantRequests, "\n"))
+	}
+	if s.releases[1].Body != "updated" || s.uploads["app.zip"] != "application/zip v2" || len(s.assets[1]) != 1 {
+		t.Errorf("got %+v, %+v, %+v", s.releases[1], s.uploads, s.assets[1])
+	}
+}
+
+func TestGitHub_Draft(t *testing.T) {
+	s, server := newGitHubStandIn()
+	defer server.Close()
+	g := NewGitHub(GitHubConfig{
+		BaseURL:          server.URL + "/api/v3",
+		Token:            "secret",
+		Owner:            "o",
+		Repo:             "r",
+		DraftPrereleases: true,
+	})
+	if _, err := g.Publish(&Release{Tag: "v1.0.0-beta", Prerelease: true}); err != nil {
+		t.Fatal(err)
+	}
+	if rel := s.releases[1]; !rel.Draft || rel.Prerelease {
+		t.Errorf("got %+v, want draft", rel)
+	}
+	if _, err := g.Publish(&Release{Tag: "v1.0.0"}); err != nil {
+		t.Fatal(err)
+	}
+	if rel := s.releases[2]; rel.Draft || rel.Prerelease {
+		t.Errorf("got %+v, want release", rel)
+	}
+	if _, err := g.Publish(&Release{Tag: "v1.0.1", Assets: []Asset{{Name: "x", URL: "https://example.com"}}}); err == nil {
+		t.Error("want error for asset without file")
+	}
+}
+
+func TestGitHub_RateLimit(t *testing.T) {
+	s, server := newGitHubStandIn()
+	defer server.Close()
+	g := NewGitHub(GitHubConfig{
+		BaseURL: server.URL + "/api/v3",
+		Token:   "secret",
+		Owner:   "o",
+		Repo:    "r",
+	})
+	waits := []time.Duration{}
+	g.api.sleep = func(d time.Duration) { waits = append(waits, d) }
+	g.api.now = func() time.Time { return time.Unix(1000000, 0) }
+
+	s.rateLimit = 2
+	if _, err := g.Publish(&Release{Tag: "v1.0.0", Notes: "notes"}); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(waits, []time.Duration{6 * time.Second, 6 * time.Second}) {
+		t.Errorf("got waits %v", waits)
+	}
+	if s.releases[1] == nil || s.releases[1].Body != "notes" {
+		t.Errorf("got %+v", s.releases)
+	}
+
+	s.rateLimit = 4
+	_, err := g.Publish(&Release{Tag: "v1.0.1"})
+	if e, ok := err.(*APIError); !ok || e.StatusCode != http.StatusForbidden {
+		t.Errorf("got %v, want rate limit error", err)
+	}
+}
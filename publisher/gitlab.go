@@ -0,0 +1,130 @@
+package publisher
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// GitLabConfig configures GitLab publisher
+type GitLabConfig struct {
+	// BaseURL of the API, e.g. https://gitlab.com/api/v4
+	BaseURL string
+	Token   string
+	// Project ID or path with namespace, e.g. group/project
+	Project string
+	// Client used for requests, http.DefaultClient when nil
+	Client *http.Client
+}
+
+// GitLab publishes releases using GitLab REST API.
+// Assets are published as release links, they must have URL.
+type GitLab struct {
+	config GitLabConfig
+	api    *apiClient
+}
+
+// NewGitLab initializes GitLab publisher
+func NewGitLab(config GitLabConfig) *GitLab {
+	return &GitLab{
+		config: config,
+		api: newAPIClient(config.BaseURL, config.Client, http.Header{
+			"Private-Token": []string{config.Token},
+		}),
+	}
+}
+
+type gitlabLink struct {
+	ID   int    `json:"id,omitempty"`
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+type gitlabAssets struct {
+	Links []gitlabLink `json:"links"`
+}
+
+type gitlabRelease struct {
+	TagName     string        `json:"tag_name,omitempty"`
+	Name        string        `json:"name"`
+	Description string        `json:"description"`
+	Assets      *gitlabAssets `json:"assets,omitempty"`
+}
+
+type gitlabReleaseResponse struct {
+	Links struct {
+		Self string `json:"self"`
+	} `json:"_links"`
+}
+
+// Publish implements Publisher interface
+func (g *GitLab) Publish(r *Release) (string, error) {
+	for _, a := range r.Assets {
+		if len(a.URL) == 0 {
+			return "", errors.Errorf("gitlab: asset '%s' has no URL", a.Name)
+		}
+	}
+	path := "/projects/" + url.PathEscape(g.config.Project) + "/releases"
+	tagPath := path + "/" + url.PathEscape(r.Tag)
+	err := g.api.do("GET", tagPath, nil, nil)
+	if IsNotFound(err) {
+		return g.create(path, r)
+	}
+	if err != nil {
+		return "", err
+	}
+	return g.update(tagPath, r)
+}
+
+func (g *GitLab) create(path string, r *Release) (string, error) {
+	in := gitlabRelease{
+		TagName:     r.Tag,
+		Name:        r.Name,
+		Description: r.Notes,
+	}
+	if len(r.Assets) > 0 {
+		in.Assets = &gitlabAssets{}
+		for _, a := range r.Assets {
+			in.Assets.Links = append(in.Assets.Links, gitlabLink{Name: a.Name, URL: a.URL})
+		}
+	}
+	out := gitlabReleaseResponse{}
+	if err := g.api.do("POST", path, &in, &out); err != nil {
+		return "", err
+	}
+	return out.Links.Self, nil
+}
+
+func (g *GitLab) update(tagPath string, r *Release) (string, error) {
+	out := gitlabReleaseResponse{}
+	err := g.api.do("PUT", tagPath, &gitlabRelease{Name: r.Name, Description: r.Notes}, &out)
+	if err != nil {
+		return "", err
+	}
+	if len(r.Assets) == 0 {
+		return out.Links.Self, nil
+	}
+	links := []gitlabLink{}
+	if err := g.api.do("GET", tagPath+"/assets/links", nil, &links); err != nil {
+		return "", err
+	}
+	byName := map[string]gitlabLink{}
+	for _, l := range links {
+		byName[l.Name] = l
+	}
+	for _, a := range r.Assets {
+		l, exists := byName[a.Name]
+		switch {
+		case !exists:
+			err = g.api.do("POST", tagPath+"/assets/links", &gitlabLink{Name: a.Name, URL: a.URL}, nil)
+		case l.URL != a.URL:
+			err = g.api.do("PUT", tagPath+"/assets/links/"+strconv.Itoa(l.ID), &gitlabLink{Name: a.Name, URL: a.URL}, nil)
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	return out.Links.Self, nil
+}
@@ -0,0 +1,231 @@
+package publisher
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blang/semver"
+	"github.com/juranki/go-semrel/angularcommit"
+	"github.com/juranki/go-semrel/release"
+	"github.com/juranki/go-semrel/semrel"
+)
+
+// gitlabStandIn implements the release endpoints of GitLab API
+type gitlabStandIn struct {
+	releases map[string]*gitlabRelease
+	requests []string
+	nextID   int
+}
+
+func newGitLabStandIn() (*gitlabStandIn, *httptest.Server) {
+	s := &gitlabStandIn{releases: map[string]*gitlabRelease{}}
+	prefix := "/api/v4/projects/group%2Fproject/releases"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.EscapedPath()
+		s.requests = append(s.requests, r.Method+" "+path)
+		if r.Header.Get("Private-Token") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if !strings.HasPrefix(path, prefix) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		parts := strings.Split(strings.TrimPrefix(path, prefix), "/")
+		body, _ := ioutil.ReadAll(r.Body)
+		in := gitlabRelease{}
+		json.Unmarshal(body, &in)
+		var tag string
+		if len(parts) > 1 {
+			tag = strings.Replace(parts[1], "%2F", "/", -1)
+		}
+		rel := s.releases[tag]
+		respond := func(rel *gitlabRelease) {
+			fmt.Fprintf(w, `{"tag_name": %q, "_links": {"self": "https://gitlab.example.com/group/project/-/releases/%s"}}`, rel.TagName, rel.TagName)
+		}
+		switch {
+		case r.Method == "POST" && len(parts) == 1:
+			s.releases[in.TagName] = &in
+			if in.Assets != nil {
+				for i := range in.Assets.Links {
+					s.nextID++
+					in.Assets.Links[i].ID = s.nextID
+				}
+			} else {
+				in.Assets = &gitlabAssets{}
+			}
+			w.WriteHeader(http.StatusCreated)
+			respond(&in)
+		case rel == nil:
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == "GET" && len(parts) == 2:
+			respond(rel)
+		case r.Method == "PUT" && len(parts) == 2:
+			rel.Name = in.Name
+			rel.Description = in.Description
+			respond(rel)
+		case r.Method == "GET" && len(parts) == 4:
+			json.NewEncoder(w).Encode(rel.Assets.Links)
+		case r.Method == "POST" && len(parts) == 4:
+			link := gitlabLink{}
+			json.Unmarshal(body, &link)
+			s.nextID++
+			link.ID = s.nextID
+			rel.Assets.Links = append(rel.Assets.Links, link)
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == "PUT" && len(parts) == 5:
+			link := gitlabLink{}
+			json.Unmarshal(body, &link)
+			id, _ := strconv.Atoi(parts[4])
+			for i := range rel.Assets.Links {
+				if rel.Assets.Links[i].ID == id {
+					rel.Assets.Links[i].URL = link.URL
+				}
+			}
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	return s, server
+}
+
+func TestGitLab_Publish(t *testing.T) {
+	s, server := newGitLabStandIn()
+	defer server.Close()
+	g := NewGitLab(GitLabConfig{
+		BaseURL: server.URL + "/api/v4/",
+		Token:   "secret",
+		Project: "group/project",
+	})
+	r := &Release{
+		Tag:    "releases/v1.0.0",
+		Name:   "v1.0.0",
+		Notes:  "## v1.0.0",
+		Assets: []Asset{{Name: "linux", URL: "https://example.com/linux"}},
+	}
+	url, err := g.Publish(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url != "https://gitlab.example.com/group/project/-/releases/releases/v1.0.0" {
+		t.Errorf("got url %s", url)
+	}
+	created := s.releases["releases/v1.0.0"]
+	if created == nil || created.Description != "## v1.0.0" || len(created.Assets.Links) != 1 {
+		t.Fatalf("got %+v", created)
+	}
+
+	r.Notes = "## v1.0.0 updated"
+	r.Assets = []Asset{
+		{Name: "linux", URL: "https://example.com/linux2"},
+		{Name: "darwin", URL: "https://example.com/darwin"},
+	}
+	s.requests = nil
+	if _, err := g.Publish(r); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"GET /api/v4/projects/group%2Fproject/releases/releases%2Fv1.0.0",
+		"PUT /api/v4/projects/group%2Fproject/releases/releases%2Fv1.0.0",
+		"GET /api/v4/projects/group%2Fproject/releases/releases%2Fv1.0.0/assets/links",
+		"PUT /api/v4/projects/group%2Fproject/releases/releases%2Fv1.0.0/assets/links/1",
+		"POST /api/v4/projects/group%2Fproject/releases/releases%2Fv1.0.0/assets/links",
+	}
+	if !reflect.DeepEqual(s.requests, want) {
+		t.Errorf("got requests\n%s\nwant\n%s", strings.Join(s.requests, "\n"), strings.Join(want, "\n"))
+	}
+	if created.Description != "## v1.0.0 updated" {
+		t.Errorf("got description %s", created.Description)
+	}
+	wantLinks := []gitlabLink{
+		{ID: 1, Name: "linux", URL: "https://example.com/linux2"},
+		{ID: 2, Name: "darwin", URL: "https://example.com/darwin"},
+	}
+	if !reflect.DeepEqual(created.Assets.Links, wantLinks) {
+		t.Errorf("got links %+v", created.Assets.Links)
+	}
+}
+
+func TestGitLab_Errors(t *testing.T) {
+	_, server := newGitLabStandIn()
+	defer server.Close()
+	g := NewGitLab(GitLabConfig{BaseURL: server.URL + "/api/v4", Token: "wrong", Project: "group/project"})
+	_, err := g.Publish(&Release{Tag: "v1.0.0"})
+	if e, ok := err.(*APIError); !ok || e.StatusCode != http.StatusUnauthorized {
+		t.Errorf("got %v, want unauthorized", err)
+	}
+	_, err = g.Publish(&Release{Tag: "v1.0.0", Assets: []Asset{{Name: "local", Path: "dist/x"}}})
+	if err == nil || err.Error() != "gitlab: asset 'local' has no URL" {
+		t.Errorf("got %v", err)
+	}
+}
+
+func TestPlugin(t *testing.T) {
+	s, server := newGitLabStandIn()
+	defer server.Close()
+	plugin := &Plugin{Publisher: NewGitLab(GitLabConfig{
+		BaseURL: server.URL + "/api/v4",
+		Token:   "secret",
+		Project: "group/project",
+	})}
+	p := release.New(&release.Options{TagPrefix: "v"},
+		&release.Analyzer{Analyzer: angularcommit.New()},
+		plugin,
+	)
+	_, err := p.Run(&semrel.VCSData{
+		CurrentVersion:    semver.MustParse("1.0.0"),
+		UnreleasedCommits: []semrel.Commit{{Msg: "feat: x"}},
+		Time:              time.Now(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.releases["v1.1.0"] == nil || plugin.URL == "" {
+		t.Errorf("got %+v, %s", s.releases, plugin.URL)
+	}
+}
+
+type recordingPublisher struct {
+	release *Release
+}
+
+func (p *recordingPublisher) Publish(r *Release) (string, error) {
+	p.release = r
+	return "", nil
+}
+
+func TestPlugin_Target(t *testing.T) {
+	data := &semrel.VCSData{
+		CurrentVersion:    semver.MustParse("1.0.0"),
+		UnreleasedCommits: []semrel.Commit{{Msg: "feat: x"}},
+		SHA:               "abc",
+	}
+	cases := []struct {
+		target string
+		want   string
+	}{
+		{"", "abc"},
+		{"main", "main"},
+	}
+	for _, c := range cases {
+		recorder := &recordingPublisher{}
+		p := release.New(nil,
+			&release.Analyzer{Analyzer: angularcommit.New()},
+			&Plugin{Publisher: recorder, Target: c.target},
+		)
+		if _, err := p.Run(data); err != nil {
+			t.Fatal(err)
+		}
+		if recorder.release.Target != c.want {
+			t.Errorf("got target '%s', want '%s'", recorder.release.Target, c.want)
+		}
+	}
+}
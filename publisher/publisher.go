@@ -0,0 +1,77 @@
+// Package publisher creates releases on code hosting services
+package publisher
+
+import (
+	"github.com/juranki/go-semrel/release"
+)
+
+// Release to be published
+type Release struct {
+	// Tag of the release, the tag is expected to exist unless
+	// the publisher creates it
+	Tag        string
+	Name       string
+	Notes      string
+	Prerelease bool
+	Assets     []Asset
+	// Target commit or branch of the tag, used by publishers that create
+	// the tag. NewRelease sets it to the released commit, which must be
+	// pushed first, see release.GitPush. The default branch of the
+	// repository is used when it is empty.
+	Target string
+}
+
+// Asset of a release. Assets are either links to files hosted elsewhere
+// (URL), or local files uploaded to the release (Path), depending on
+// what the service supports.
+type Asset struct {
+	Name string
+	URL  string
+	Path string
+}
+
+// Publisher publishes releases. If the release exists, it is updated.
+type Publisher interface {
+	// Publish returns the web URL of the release
+	Publish(r *Release) (string, error)
+}
+
+// NewRelease returns Release for the release being made in ctx
+func NewRelease(ctx *release.Context, assets []Asset) *Release {
+	return &Release{
+		Tag:        ctx.Tag(),
+		Name:       ctx.Tag(),
+		Notes:      ctx.Notes,
+		Prerelease: len(ctx.Release.NextVersion.Pre) > 0,
+		Assets:     assets,
+		Target:     ctx.Commit,
+	}
+}
+
+// Plugin publishes releases in the Publish stage of release.Pipeline
+type Plugin struct {
+	Publisher Publisher
+	Assets    []Asset
+	// Target of created tags instead of the released commit,
+	// see Release.Target
+	Target string
+	// URL of the published release, set in Publish stage
+	URL string
+}
+
+// Name implements release.Plugin interface
+func (p *Plugin) Name() string { return "publisher" }
+
+// Publish implements release.Publisher interface
+func (p *Plugin) Publish(ctx *release.Context) error {
+	r := NewRelease(ctx, p.Assets)
+	if len(p.Target) > 0 {
+		r.Target = p.Target
+	}
+	url, err := p.Publisher.Publish(r)
+	if err != nil {
+		return err
+	}
+	p.URL = url
+	return nil
+}
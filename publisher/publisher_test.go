@@ -0,0 +1,105 @@
+package publisher
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/blang/semver"
+	"github.com/juranki/go-semrel/angularcommit"
+	"github.com/juranki/go-semrel/bumpfiles"
+	"github.com/juranki/go-semrel/release"
+	"github.com/juranki/go-semrel/semrel"
+	"github.com/pkg/errors"
+	git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/config"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport/client"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport/server"
+	"gopkg.in/src-d/go-git.v4/storage/memory"
+)
+
+// remotePublisher checks that the tag and the target exist in remote,
+// like a code hosting service would
+type remotePublisher struct {
+	remote  *memory.Storage
+	release *Release
+}
+
+func (p *remotePublisher) Publish(r *Release) (string, error) {
+	p.release = r
+	if _, err := p.remote.Reference(plumbing.NewTagReferenceName(r.Tag)); err != nil {
+		return "", errors.Wrap(err, r.Tag)
+	}
+	if _, err := p.remote.EncodedObject(plumbing.CommitObject, plumbing.NewHash(r.Target)); err != nil {
+		return "", errors.Wrap(err, r.Target)
+	}
+	return "", nil
+}
+
+func TestPipeline_Push(t *testing.T) {
+	remote := memory.NewStorage()
+	if _, err := git.Init(remote, nil); err != nil {
+		t.Fatal(err)
+	}
+	client.InstallProtocol("test", server.NewClient(server.MapLoader{"test://host/repo": remote}))
+	defer client.InstallProtocol("test", nil)
+
+	dir, err := ioutil.TempDir("", "publisher")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	r, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{"test://host/repo"}}); err != nil {
+		t.Fatal(err)
+	}
+	w, err := r.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ioutil.WriteFile(filepath.Join(dir, "package.json"), []byte("{\n  \"version\": \"1.0.0\"\n}\n"), 0644)
+	w.Add("package.json")
+	sig := &object.Signature{Name: "a", Email: "a@b", When: time.Now()}
+	head, err := w.Commit("feat: x", &git.CommitOptions{Author: sig})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Push(&git.PushOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := &remotePublisher{remote: remote}
+	p := release.New(&release.Options{TagPrefix: "v"},
+		&release.Analyzer{Analyzer: angularcommit.New()},
+		&release.BumpFiles{Files: []bumpfiles.File{{Path: filepath.Join(dir, "package.json")}}},
+		&release.GitCommit{Repository: r, Author: sig},
+		&release.GitTag{Repository: r},
+		&release.GitPush{Repository: r},
+		&Plugin{Publisher: recorder},
+	)
+	ctx, err := p.Run(&semrel.VCSData{
+		CurrentVersion:    semver.MustParse("1.0.0"),
+		UnreleasedCommits: []semrel.Commit{{Msg: "feat: x", SHA: head.String()}},
+		SHA:               head.String(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recorder.release.Target != ctx.Commit || ctx.Commit == head.String() {
+		t.Errorf("got target %s, want the release commit %s", recorder.release.Target, ctx.Commit)
+	}
+	branch, err := remote.Reference(plumbing.NewBranchReferenceName("master"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if branch.Hash().String() != ctx.Commit {
+		t.Errorf("remote master is %s, want %s", branch.Hash(), ctx.Commit)
+	}
+}
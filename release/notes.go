@@ -0,0 +1,121 @@
+package release
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/juranki/go-semrel/semrel"
+)
+
+// Section of release notes lists changes of a category
+type Section struct {
+	Category string
+	Title    string
+}
+
+// DefaultSections match the categories of angularcommit.Analyzer
+var DefaultSections = []Section{
+	{"breaking", "Breaking changes"},
+	{"feature", "Features"},
+	{"fix", "Bug fixes"},
+}
+
+// Markdown renders release notes. Changes that implement semrel.Describer
+// are listed with scope, subject, commit and references, others with their
+// default format. References are linked where they appear in the subject,
+// the rest are listed after the commit. Commits are linked when the
+// repository is known. Categories not in sections are left out.
+func Markdown(release *semrel.ReleaseData, title string, sections []Section) string {
+	b := strings.Builder{}
+	fmt.Fprintf(&b, "## %s (%s)\n", title, release.Time.Format("2006-01-02"))
+	for _, section := range sections {
+		changes := release.Changes[section.Category]
+		if len(changes) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n### %s\n\n", section.Title)
+		for _, change := range changes {
+			b.WriteString("- ")
+			b.WriteString(markdownItem(change, release.Repository))
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+func markdownItem(change semrel.Change, repository *semrel.RepositoryInfo) string {
+	d, ok := change.(semrel.Describer)
+	if !ok {
+		return fmt.Sprint(change)
+	}
+	desc := d.Describe()
+	b := strings.Builder{}
+	if len(desc.Scope) > 0 {
+		fmt.Fprintf(&b, "**%s:** ", desc.Scope)
+	}
+	subject, rest := linkReferences(desc.Subject, desc.References)
+	b.WriteString(subject)
+	sha := desc.SHA
+	if len(sha) > 7 {
+		sha = sha[:7]
+	}
+	if len(sha) > 0 {
+		if repository != nil && len(repository.CommitURL) > 0 {
+			sha = fmt.Sprintf("[%s](%s)", sha, repository.Commit(desc.SHA))
+		}
+		rest = append([]string{sha}, rest...)
+	}
+	if len(rest) > 0 {
+		fmt.Fprintf(&b, " (%s)", strings.Join(rest, ", "))
+	}
+	if len(desc.Breaking) > 0 {
+		b.WriteString("\n\n  ")
+		b.WriteString(strings.Replace(desc.Breaking, "\n", "\n  ", -1))
+	}
+	return b.String()
+}
+
+// linkReferences replaces references in text with Markdown links, and
+// returns the links of references not found in text
+func linkReferences(text string, refs []semrel.Reference) (string, []string) {
+	rest := []string{}
+	for _, ref := range refs {
+		link := markdownLink(ref)
+		i := indexReference(text, ref.ID)
+		if i < 0 {
+			rest = append(rest, link)
+			continue
+		}
+		text = text[:i] + link + text[i+len(ref.ID):]
+	}
+	return text, rest
+}
+
+func markdownLink(ref semrel.Reference) string {
+	if len(ref.URL) == 0 {
+		return ref.ID
+	}
+	return fmt.Sprintf("[%s](%s)", ref.ID, ref.URL)
+}
+
+// indexReference returns the index of the first occurrence of id in text
+// that is not part of a longer word or an earlier link, or -1
+func indexReference(text, id string) int {
+	isWord := func(c byte) bool {
+		return c == '_' || c == '-' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+	}
+	for offset := 0; offset < len(text); {
+		i := strings.Index(text[offset:], id)
+		if i < 0 {
+			return -1
+		}
+		i += offset
+		end := i + len(id)
+		if (i == 0 || !isWord(text[i-1]) && text[i-1] != '[') &&
+			(end == len(text) || !isWord(text[end]) && text[end] != ']') {
+			return i
+		}
+		offset = i + 1
+	}
+	return -1
+}
@@ -0,0 +1,276 @@
+package release
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/juranki/go-semrel/bumpfiles"
+	"github.com/juranki/go-semrel/semrel"
+	"github.com/pkg/errors"
+	git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/config"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport"
+)
+
+// Analyzer plugin determines the next version with semrel.Release
+type Analyzer struct {
+	Analyzer semrel.ChangeAnalyzer
+}
+
+// Name implements Plugin interface
+func (a *Analyzer) Name() string { return "analyzer" }
+
+// AnalyzeCommits implements CommitAnalyzer interface
+func (a *Analyzer) AnalyzeCommits(ctx *Context) error {
+	release, err := semrel.Release(ctx.VCSData, a.Analyzer)
+	if err != nil {
+		return err
+	}
+	ctx.Release = release
+	return nil
+}
+
+// Notes plugin renders release notes in Markdown. The title links to
+// the changes since previous release when the repository is known.
+type Notes struct {
+	// Sections of the notes, DefaultSections when nil
+	Sections []Section
+}
+
+// Name implements Plugin interface
+func (n *Notes) Name() string { return "notes" }
+
+// GenerateNotes implements NotesGenerator interface
+func (n *Notes) GenerateNotes(ctx *Context) (string, error) {
+	sections := n.Sections
+	if sections == nil {
+		sections = DefaultSections
+	}
+	title := ctx.Tag()
+	if url := ctx.CompareURL(); len(url) > 0 {
+		title = fmt.Sprintf("[%s](%s)", title, url)
+	}
+	return Markdown(ctx.Release, title, sections), nil
+}
+
+// BumpFiles plugin updates the version in project files
+type BumpFiles struct {
+	Files []bumpfiles.File
+}
+
+// Name implements Plugin interface
+func (b *BumpFiles) Name() string { return "bumpfiles" }
+
+// VerifyRelease implements ReleaseVerifier interface. Files are checked
+// without modifying them.
+func (b *BumpFiles) VerifyRelease(ctx *Context) error {
+	results, err := bumpfiles.Update(b.Files, ctx.Release.NextVersion, true)
+	if err != nil {
+		return err
+	}
+	for _, r := range results {
+		if len(r.Diff) > 0 {
+			semrel.Logf("%s", r.Diff)
+		}
+	}
+	return nil
+}
+
+// Prepare implements Preparer interface
+func (b *BumpFiles) Prepare(ctx *Context) error {
+	_, err := bumpfiles.Update(b.Files, ctx.Release.NextVersion, false)
+	return err
+}
+
+// DefaultCommitMessage of GitCommit
+const DefaultCommitMessage = "chore(release): %s"
+
+// GitCommit plugin commits the changes that the plugins before it made
+// in Prepare stage, e.g. BumpFiles, so that the release tag includes them.
+// Untracked files are not committed. The commit is pushed by GitPush.
+type GitCommit struct {
+	Repository *git.Repository
+	Author     *object.Signature
+	// Message of the commit, %s is replaced with the release tag.
+	// DefaultCommitMessage when empty.
+	Message string
+}
+
+// Name implements Plugin interface
+func (g *GitCommit) Name() string { return "gitcommit" }
+
+// VerifyConditions implements ConditionVerifier interface
+func (g *GitCommit) VerifyConditions(ctx *Context) error {
+	if g.Author == nil {
+		return errors.New("author is required")
+	}
+	return nil
+}
+
+// Prepare implements Preparer interface. Context.Commit is set to
+// the created commit.
+func (g *GitCommit) Prepare(ctx *Context) error {
+	w, err := g.Repository.Worktree()
+	if err != nil {
+		return err
+	}
+	status, err := w.Status()
+	if err != nil {
+		return err
+	}
+	changed := false
+	for file, s := range status {
+		switch {
+		case s.Worktree == git.Untracked, s.Worktree == git.Unmodified && s.Staging == git.Unmodified:
+			continue
+		case s.Worktree == git.Deleted:
+			_, err = w.Remove(file)
+		case s.Worktree != git.Unmodified:
+			_, err = w.Add(file)
+		}
+		if err != nil {
+			return errors.Wrap(err, file)
+		}
+		changed = true
+	}
+	if !changed {
+		semrel.Logf("nothing to commit")
+		return nil
+	}
+	message := g.Message
+	if len(message) == 0 {
+		message = DefaultCommitMessage
+	}
+	author := *g.Author
+	if author.When.IsZero() {
+		author.When = time.Now()
+	}
+	hash, err := w.Commit(fmt.Sprintf(message, ctx.Tag()), &git.CommitOptions{Author: &author})
+	if err != nil {
+		return err
+	}
+	semrel.Logf("created release commit %.7s", hash)
+	ctx.Commit = hash.String()
+	return nil
+}
+
+// GitTag plugin tags the released commit with the release tag, see
+// Context.Commit. HEAD is tagged when the commit is not known.
+type GitTag struct {
+	Repository *git.Repository
+	// Tagger of annotated tag. Lightweight tag is created when nil.
+	Tagger *object.Signature
+}
+
+// Name implements Plugin interface
+func (g *GitTag) Name() string { return "gittag" }
+
+// VerifyRelease implements ReleaseVerifier interface
+func (g *GitTag) VerifyRelease(ctx *Context) error {
+	if !ctx.Released() {
+		return nil
+	}
+	_, err := g.Repository.Tag(ctx.Tag())
+	if err == nil {
+		return errors.Errorf("tag %s already exists", ctx.Tag())
+	}
+	if err != git.ErrTagNotFound {
+		return err
+	}
+	return nil
+}
+
+// Publish implements Publisher interface
+func (g *GitTag) Publish(ctx *Context) error {
+	target := plumbing.NewHash(ctx.Commit)
+	if len(ctx.Commit) == 0 {
+		head, err := g.Repository.Head()
+		if err != nil {
+			return errors.Wrap(err, "get HEAD")
+		}
+		target = head.Hash()
+	}
+	var opts *git.CreateTagOptions
+	if g.Tagger != nil {
+		tagger := *g.Tagger
+		if tagger.When.IsZero() {
+			tagger.When = time.Now()
+		}
+		message := ctx.Notes
+		if len(message) == 0 {
+			message = ctx.Tag()
+		}
+		opts = &git.CreateTagOptions{
+			Tagger:  &tagger,
+			Message: message,
+		}
+	}
+	_, err := g.Repository.CreateTag(ctx.Tag(), target, opts)
+	return err
+}
+
+// GitPush plugin pushes the release tag and the release commit that
+// GitCommit created to a remote repository. It runs in Publish stage, so
+// it must be placed after GitTag and before the plugins that publish the
+// release on a code hosting service, as they refer to the pushed commit.
+type GitPush struct {
+	Repository *git.Repository
+	// Remote name, "origin" when empty
+	Remote string
+	// Branch that receives the release commit, the branch of HEAD
+	// when empty
+	Branch string
+	// Auth of the remote, nil for the defaults of the transport
+	Auth transport.AuthMethod
+}
+
+// Name implements Plugin interface
+func (g *GitPush) Name() string { return "gitpush" }
+
+func (g *GitPush) remote() string {
+	if len(g.Remote) == 0 {
+		return git.DefaultRemoteName
+	}
+	return g.Remote
+}
+
+// VerifyConditions implements ConditionVerifier interface
+func (g *GitPush) VerifyConditions(ctx *Context) error {
+	_, err := g.Repository.Remote(g.remote())
+	return errors.Wrap(err, g.remote())
+}
+
+// Publish implements Publisher interface
+func (g *GitPush) Publish(ctx *Context) error {
+	tag := plumbing.NewTagReferenceName(ctx.Tag())
+	refSpecs := []config.RefSpec{config.RefSpec(tag + ":" + tag)}
+	if ctx.Commit != ctx.VCSData.SHA {
+		// GitCommit created the release commit on HEAD
+		head, err := g.Repository.Head()
+		if err != nil {
+			return errors.Wrap(err, "get HEAD")
+		}
+		if head.Hash().String() != ctx.Commit {
+			return errors.Errorf("HEAD is not the release commit %.7s", ctx.Commit)
+		}
+		branch := g.Branch
+		if len(branch) == 0 && head.Name().IsBranch() {
+			branch = head.Name().Short()
+		}
+		if len(branch) == 0 {
+			return errors.New("branch of the release commit is not known")
+		}
+		refSpecs = append(refSpecs, config.RefSpec(head.Name()+":"+plumbing.NewBranchReferenceName(branch)))
+	}
+	err := g.Repository.Push(&git.PushOptions{
+		RemoteName: g.remote(),
+		RefSpecs:   refSpecs,
+		Auth:       g.Auth,
+	})
+	if err == git.NoErrAlreadyUpToDate {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,250 @@
+// Package release runs the release lifecycle on top of semrel.Release
+//
+// A release is made of stages, similar to semantic-release:
+//
+//	VerifyConditions  check that release can be made, e.g. credentials exist
+//	AnalyzeCommits    determine next version, sets Context.Release
+//	VerifyRelease     check the release that is about to be made
+//	GenerateNotes     produce release notes, sets Context.Notes
+//	Prepare           update files, create commits
+//	Publish           create and push tags, publish releases
+//	Success / Fail    report the outcome
+//
+// Plugins implement one or more of the stage interfaces. The stages up to
+// GenerateNotes are read-only, they are the only ones run in dry-run mode.
+// Stages after VerifyRelease are skipped when there is nothing to release.
+package release
+
+import (
+	"strings"
+
+	"github.com/blang/semver"
+	"github.com/juranki/go-semrel/semrel"
+	"github.com/pkg/errors"
+)
+
+// Plugin is a named set of stage hooks
+type Plugin interface {
+	Name() string
+}
+
+// ConditionVerifier is a plugin that runs in VerifyConditions stage
+type ConditionVerifier interface {
+	VerifyConditions(ctx *Context) error
+}
+
+// CommitAnalyzer is a plugin that runs in AnalyzeCommits stage
+type CommitAnalyzer interface {
+	AnalyzeCommits(ctx *Context) error
+}
+
+// ReleaseVerifier is a plugin that runs in VerifyRelease stage
+type ReleaseVerifier interface {
+	VerifyRelease(ctx *Context) error
+}
+
+// NotesGenerator is a plugin that runs in GenerateNotes stage. Notes of
+// all plugins are joined with a blank line.
+type NotesGenerator interface {
+	GenerateNotes(ctx *Context) (string, error)
+}
+
+// Preparer is a plugin that runs in Prepare stage
+type Preparer interface {
+	Prepare(ctx *Context) error
+}
+
+// Publisher is a plugin that runs in Publish stage
+type Publisher interface {
+	Publish(ctx *Context) error
+}
+
+// SuccessNotifier is a plugin that runs after successful release
+type SuccessNotifier interface {
+	Success(ctx *Context) error
+}
+
+// FailNotifier is a plugin that runs after a failed release.
+// Context.Err contains the error.
+type FailNotifier interface {
+	Fail(ctx *Context) error
+}
+
+// Context is shared by plugins during a release
+type Context struct {
+	DryRun    bool
+	TagPrefix string
+	VCSData   *semrel.VCSData
+	// Release is set in AnalyzeCommits stage
+	Release *semrel.ReleaseData
+	// Notes is set in GenerateNotes stage
+	Notes string
+	// Commit is the SHA of the released commit, VCSData.SHA or the release
+	// commit that GitCommit creates in Prepare stage
+	Commit string
+	// Err is set before Fail stage
+	Err error
+}
+
+// Tag returns the name of the release tag
+func (ctx *Context) Tag() string {
+	if ctx.Release == nil {
+		return ""
+	}
+	return ctx.TagPrefix + ctx.Release.NextVersion.String()
+}
+
+// CompareURL returns the URL of changes since previous release, or empty
+// string if the repository or previous release is not known
+func (ctx *Context) CompareURL() string {
+	if ctx.Release == nil || ctx.Release.Repository == nil {
+		return ""
+	}
+	if ctx.VCSData != nil && ctx.VCSData.PreviousRelease != nil {
+		return ctx.Release.Repository.Compare(ctx.VCSData.PreviousRelease.Name, ctx.Tag())
+	}
+	current := ctx.Release.CurrentVersion
+	if current.Equals(semver.Version{}) {
+		return ""
+	}
+	return ctx.Release.Repository.Compare(ctx.TagPrefix+current.String(), ctx.Tag())
+}
+
+// Released reports whether there is something to release
+func (ctx *Context) Released() bool {
+	return ctx.Release != nil && ctx.Release.BumpLevel != semrel.NoBump
+}
+
+// Options control a release run
+type Options struct {
+	// DryRun runs only the read-only stages
+	DryRun bool
+	// TagPrefix precedes the version in release tag
+	TagPrefix string
+}
+
+// Pipeline runs plugins through the release stages
+type Pipeline struct {
+	options *Options
+	plugins []Plugin
+}
+
+// New initializes Pipeline with options and plugins. Plugins run in
+// the given order within each stage.
+func New(options *Options, plugins ...Plugin) *Pipeline {
+	if options == nil {
+		options = &Options{}
+	}
+	return &Pipeline{
+		options: options,
+		plugins: plugins,
+	}
+}
+
+// Run makes a release of the unreleased commits in data. The returned
+// context is valid also when an error is returned.
+func (p *Pipeline) Run(data *semrel.VCSData) (*Context, error) {
+	ctx := &Context{
+		DryRun:    p.options.DryRun,
+		TagPrefix: p.options.TagPrefix,
+		VCSData:   data,
+		Commit:    data.SHA,
+	}
+	err := p.run(ctx)
+	if err == nil || ctx.DryRun {
+		return ctx, err
+	}
+	ctx.Err = err
+	for _, plugin := range p.plugins {
+		if f, ok := plugin.(FailNotifier); ok {
+			if ferr := f.Fail(ctx); ferr != nil {
+				semrel.Logf("WARNING: fail: %s: %s", plugin.Name(), ferr)
+			}
+		}
+	}
+	return ctx, err
+}
+
+func (p *Pipeline) run(ctx *Context) error {
+	err := p.each("verifyConditions", func(plugin Plugin) error {
+		if v, ok := plugin.(ConditionVerifier); ok {
+			return v.VerifyConditions(ctx)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	err = p.each("analyzeCommits", func(plugin Plugin) error {
+		if a, ok := plugin.(CommitAnalyzer); ok {
+			return a.AnalyzeCommits(ctx)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if ctx.Release == nil {
+		return errors.New("analyzeCommits: no plugin analyzed commits")
+	}
+	err = p.each("verifyRelease", func(plugin Plugin) error {
+		if v, ok := plugin.(ReleaseVerifier); ok {
+			return v.VerifyRelease(ctx)
+		}
+		return nil
+	})
+	if err != nil || !ctx.Released() {
+		return err
+	}
+	notes := []string{}
+	err = p.each("generateNotes", func(plugin Plugin) error {
+		if g, ok := plugin.(NotesGenerator); ok {
+			n, err := g.GenerateNotes(ctx)
+			if len(strings.TrimSpace(n)) > 0 {
+				notes = append(notes, strings.TrimSpace(n))
+			}
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	ctx.Notes = strings.Join(notes, "\n\n")
+	if ctx.DryRun {
+		return nil
+	}
+	err = p.each("prepare", func(plugin Plugin) error {
+		if pr, ok := plugin.(Preparer); ok {
+			return pr.Prepare(ctx)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	err = p.each("publish", func(plugin Plugin) error {
+		if pub, ok := plugin.(Publisher); ok {
+			return pub.Publish(ctx)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return p.each("success", func(plugin Plugin) error {
+		if s, ok := plugin.(SuccessNotifier); ok {
+			return s.Success(ctx)
+		}
+		return nil
+	})
+}
+
+func (p *Pipeline) each(stage string, f func(Plugin) error) error {
+	for _, plugin := range p.plugins {
+		if err := f(plugin); err != nil {
+			return errors.Wrapf(err, "%s: %s", stage, plugin.Name())
+		}
+	}
+	return nil
+}
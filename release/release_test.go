@@ -0,0 +1,379 @@
+package release
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blang/semver"
+	"github.com/juranki/go-semrel/angularcommit"
+	"github.com/juranki/go-semrel/semrel"
+	"gopkg.in/src-d/go-billy.v4/memfs"
+	git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/config"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport/client"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport/server"
+	"gopkg.in/src-d/go-git.v4/storage/memory"
+)
+
+// recorder is a plugin that implements all stages and records the calls
+type recorder struct {
+	calls *[]string
+	fail  string
+}
+
+func (r *recorder) Name() string { return "recorder" }
+
+func (r *recorder) call(stage string) error {
+	*r.calls = append(*r.calls, stage)
+	if stage == r.fail {
+		return errors.New("failed")
+	}
+	return nil
+}
+
+func (r *recorder) VerifyConditions(ctx *Context) error { return r.call("verifyConditions") }
+func (r *recorder) AnalyzeCommits(ctx *Context) error   { return r.call("analyzeCommits") }
+func (r *recorder) VerifyRelease(ctx *Context) error    { return r.call("verifyRelease") }
+func (r *recorder) GenerateNotes(ctx *Context) (string, error) {
+	return "recorded", r.call("generateNotes")
+}
+func (r *recorder) Prepare(ctx *Context) error { return r.call("prepare") }
+func (r *recorder) Publish(ctx *Context) error { return r.call("publish") }
+func (r *recorder) Success(ctx *Context) error { return r.call("success") }
+func (r *recorder) Fail(ctx *Context) error    { return r.call("fail") }
+
+func vcsData(msgs ...string) *semrel.VCSData {
+	data := &semrel.VCSData{
+		CurrentVersion: semver.MustParse("1.2.3"),
+		Time:           time.Date(2019, 8, 1, 0, 0, 0, 0, time.UTC),
+	}
+	for _, msg := range msgs {
+		data.UnreleasedCommits = append(data.UnreleasedCommits, semrel.Commit{
+			Msg: msg,
+			SHA: "0123456789abcdef",
+		})
+	}
+	return data
+}
+
+func TestPipeline_Stages(t *testing.T) {
+	all := []string{"verifyConditions", "analyzeCommits", "verifyRelease", "generateNotes", "prepare", "publish", "success"}
+	tests := []struct {
+		name    string
+		dryRun  bool
+		msgs    []string
+		fail    string
+		want    []string
+		wantErr string
+	}{
+		{"release", false, []string{"feat: x"}, "", all, ""},
+		{"dry run", true, []string{"feat: x"}, "", all[:4], ""},
+		{"nothing to release", false, []string{"chore: x"}, "", all[:3], ""},
+		{"fail", false, []string{"feat: x"}, "prepare", append(all[:5:5], "fail"), "prepare: recorder: failed"},
+		{"fail in dry run", true, []string{"feat: x"}, "verifyRelease", all[:3], "verifyRelease: recorder: failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := []string{}
+			p := New(&Options{DryRun: tt.dryRun, TagPrefix: "v"},
+				&Analyzer{Analyzer: angularcommit.New()},
+				&recorder{calls: &calls, fail: tt.fail},
+			)
+			ctx, err := p.Run(vcsData(tt.msgs...))
+			if len(tt.wantErr) > 0 {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("got error %v, want %s", err, tt.wantErr)
+				}
+				if ctx.Err != nil && ctx.Err != err {
+					t.Errorf("got ctx.Err %v", ctx.Err)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(calls, tt.want) {
+				t.Errorf("got %v, want %v", calls, tt.want)
+			}
+		})
+	}
+}
+
+func TestPipeline_Notes(t *testing.T) {
+	p := New(&Options{DryRun: true, TagPrefix: "v"},
+		&Analyzer{Analyzer: angularcommit.New()},
+		&Notes{},
+	)
+	ctx, err := p.Run(vcsData("feat(api): add x", "fix: crash", "refactor: y\n\nBREAKING CHANGE: y is\ngone", "chore: z"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ctx.Tag() != "v2.0.0" {
+		t.Errorf("got tag %s, want v2.0.0", ctx.Tag())
+	}
+	want := `## v2.0.0 (2019-08-01)
+
+### Breaking changes
+
+- y (0123456)
+
+  y is
+  gone
+
+### Features
+
+- **api:** add x (0123456)
+
+### Bug fixes
+
+- crash (0123456)`
+	if ctx.Notes != want {
+		t.Errorf("got\n%s\nwant\n%s", ctx.Notes, want)
+	}
+}
+
+func TestMarkdown_References(t *testing.T) {
+	issues, _ := semrel.NewReferencePattern(`#(\d+)`, "https://github.com/o/r/issues/$1")
+	jira, _ := semrel.NewReferencePattern(`PROJ-\d+`, "https://jira.example.com/browse/$0")
+	analyzer := angularcommit.NewWithOptions(&angularcommit.Options{
+		FixTypes:          []string{"fix"},
+		ReferencePatterns: []semrel.ReferencePattern{issues, jira},
+	})
+	data := vcsData("fix: crash on #1 and #12 (#1)\n\nCloses: PROJ-7, #3")
+	release, err := semrel.Release(data, analyzer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "- crash on [#1](https://github.com/o/r/issues/1) and [#12](https://github.com/o/r/issues/12) (#1) " +
+		"(0123456, [PROJ-7](https://jira.example.com/browse/PROJ-7), [#3](https://github.com/o/r/issues/3))"
+	if got := Markdown(release, "v1.2.4", DefaultSections); !strings.Contains(got, want) {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestNotes_Links(t *testing.T) {
+	p := New(&Options{DryRun: true, TagPrefix: "v"},
+		&Analyzer{Analyzer: angularcommit.New()},
+		&Notes{},
+	)
+	data := vcsData("fix: crash")
+	data.Repository = semrel.NewRepositoryInfo("https://github.com/o/r", semrel.UnknownFlavor)
+	ctx, err := p.Run(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `## [v1.2.4](https://github.com/o/r/compare/v1.2.3...v1.2.4) (2019-08-01)
+
+### Bug fixes
+
+- crash ([0123456](https://github.com/o/r/commit/0123456789abcdef))`
+	if ctx.Notes != want {
+		t.Errorf("got\n%s\nwant\n%s", ctx.Notes, want)
+	}
+}
+
+func TestPipeline_NoAnalyzer(t *testing.T) {
+	_, err := New(nil).Run(vcsData("feat: x"))
+	if err == nil {
+		t.Error("want error without analyzer")
+	}
+}
+
+func TestGitTag(t *testing.T) {
+	r, err := git.Init(memory.NewStorage(), memfs.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := r.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig := &object.Signature{Name: "a", Email: "a@b", When: time.Now()}
+	head, err := w.Commit("feat: x", &git.CommitOptions{Author: sig})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := New(&Options{TagPrefix: "v"},
+		&Analyzer{Analyzer: angularcommit.New()},
+		&Notes{},
+		&GitTag{Repository: r, Tagger: &object.Signature{Name: "semrel", Email: "semrel@example.com"}},
+	)
+	if _, err := p.Run(vcsData("feat: x")); err != nil {
+		t.Fatal(err)
+	}
+	ref, err := r.Tag("v1.3.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tag, err := r.TagObject(ref.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tag.Target != head || tag.Tagger.Name != "semrel" {
+		t.Errorf("got %+v", tag)
+	}
+
+	if _, err := p.Run(vcsData("feat: x")); err == nil {
+		t.Error("want error for existing tag")
+	}
+}
+
+func TestGitCommit(t *testing.T) {
+	fs := memfs.New()
+	r, err := git.Init(memory.NewStorage(), fs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := r.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile := func(name, content string) {
+		f, err := fs.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write([]byte(content))
+		f.Close()
+	}
+	writeFile("VERSION", "1.2.0")
+	writeFile("old.md", "x")
+	w.Add("VERSION")
+	w.Add("old.md")
+	sig := &object.Signature{Name: "a", Email: "a@b", When: time.Now()}
+	head, err := w.Commit("feat: x", &git.CommitOptions{Author: sig})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// plugins that run before GitCommit in Prepare stage
+	prepare := &preparer{func(ctx *Context) error {
+		writeFile("VERSION", ctx.Release.NextVersion.String())
+		writeFile("untracked.txt", "x")
+		_, err := w.Remove("old.md")
+		return err
+	}}
+	p := New(&Options{TagPrefix: "v"},
+		&Analyzer{Analyzer: angularcommit.New()},
+		prepare,
+		&GitCommit{Repository: r, Author: &object.Signature{Name: "semrel", Email: "semrel@example.com"}},
+		&GitTag{Repository: r},
+	)
+	ctx, err := p.Run(vcsData("feat: x"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	commit, err := r.CommitObject(plumbing.NewHash(ctx.Commit))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if commit.Message != "chore(release): v1.3.0" || commit.ParentHashes[0] != head {
+		t.Errorf("got %+v", commit)
+	}
+	if f, err := commit.File("VERSION"); err != nil {
+		t.Error(err)
+	} else if content, _ := f.Contents(); content != "1.3.0" {
+		t.Errorf("got VERSION %s", content)
+	}
+	if _, err := commit.File("old.md"); err == nil {
+		t.Error("old.md was not removed")
+	}
+	if _, err := commit.File("untracked.txt"); err == nil {
+		t.Error("untracked.txt was committed")
+	}
+	ref, err := r.Tag("v1.3.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ref.Hash() != commit.Hash {
+		t.Errorf("tag points to %s, want %s", ref.Hash(), commit.Hash)
+	}
+
+	if _, err := New(nil, &Analyzer{Analyzer: angularcommit.New()}, &GitCommit{Repository: r}).Run(vcsData("feat: x")); err == nil {
+		t.Error("want error without author")
+	}
+}
+
+func TestGitPush(t *testing.T) {
+	remote := memory.NewStorage()
+	if _, err := git.Init(remote, nil); err != nil {
+		t.Fatal(err)
+	}
+	client.InstallProtocol("test", server.NewClient(server.MapLoader{"test://host/repo": remote}))
+	defer client.InstallProtocol("test", nil)
+
+	r, err := git.Init(memory.NewStorage(), memfs.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.CreateRemote(&config.RemoteConfig{Name: "upstream", URLs: []string{"test://host/repo"}}); err != nil {
+		t.Fatal(err)
+	}
+	w, err := r.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig := &object.Signature{Name: "a", Email: "a@b", When: time.Now()}
+	head, err := w.Commit("feat: x", &git.CommitOptions{Author: sig})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// CI jobs check out the commit
+	if err := w.Checkout(&git.CheckoutOptions{Hash: head}); err != nil {
+		t.Fatal(err)
+	}
+	data := vcsData("feat: x")
+	data.SHA = head.String()
+	commit := &preparer{func(ctx *Context) error {
+		hash, err := w.Commit("chore(release): "+ctx.Tag(), &git.CommitOptions{Author: sig})
+		ctx.Commit = hash.String()
+		return err
+	}}
+
+	p := New(&Options{TagPrefix: "v"},
+		&Analyzer{Analyzer: angularcommit.New()},
+		commit,
+		&GitTag{Repository: r},
+		&GitPush{Repository: r, Remote: "upstream"},
+	)
+	if _, err := p.Run(data); err == nil || !strings.Contains(err.Error(), "branch of the release commit is not known") {
+		t.Errorf("got %v, want error for unknown branch", err)
+	}
+
+	// tagged before the push failed
+	if err := r.DeleteTag("v1.3.0"); err != nil {
+		t.Fatal(err)
+	}
+	p = New(&Options{TagPrefix: "v"},
+		&Analyzer{Analyzer: angularcommit.New()},
+		commit,
+		&GitTag{Repository: r},
+		&GitPush{Repository: r, Remote: "upstream", Branch: "main"},
+	)
+	ctx, err := p.Run(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []plumbing.ReferenceName{"refs/heads/main", "refs/tags/v1.3.0"} {
+		ref, err := remote.Reference(name)
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		if ref.Hash().String() != ctx.Commit {
+			t.Errorf("%s is %s, want %s", name, ref.Hash(), ctx.Commit)
+		}
+	}
+
+	if _, err := New(nil, &GitPush{Repository: r}).Run(data); err == nil {
+		t.Error("want error for missing remote")
+	}
+}
+
+type preparer struct {
+	prepare func(ctx *Context) error
+}
+
+func (p *preparer) Name() string               { return "preparer" }
+func (p *preparer) Prepare(ctx *Context) error { return p.prepare(ctx) }
@@ -0,0 +1,156 @@
+// Package rulecommit analyzes commit messages with an ordered list of
+// regular expression rules
+//
+// The first rule that matches the message decides the bump level and
+// the category of the change. Submatches named `type`, `scope` and
+// `subject` describe the change in release notes.
+package rulecommit
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/juranki/go-semrel/semrel"
+)
+
+// Rule maps matching messages to bump level and category
+type Rule struct {
+	// Name identifies the rule in changes, `rules[i]` when empty
+	Name string
+	// Pattern is matched against the whole message, use ^ to anchor it
+	// to the start of the head line
+	Pattern   string
+	BumpLevel semrel.BumpLevel
+	// Category of the change, derived from BumpLevel when empty
+	Category string
+}
+
+// InvalidRuleError is returned by New when the pattern of a rule
+// is not a valid regular expression
+type InvalidRuleError struct {
+	// Index of the rule
+	Index int
+	Rule  Rule
+	Err   error
+}
+
+func (e *InvalidRuleError) Error() string {
+	return fmt.Sprintf("invalid pattern of rule '%s': %s", e.Rule.Name, e.Err)
+}
+
+// Analyzer is a semrel.Analyzer instance that parses commits
+// with rules
+type Analyzer struct {
+	rules    []Rule
+	patterns []*regexp.Regexp
+}
+
+// New compiles rules into Analyzer. Error is of type *InvalidRuleError.
+func New(rules []Rule) (*Analyzer, error) {
+	a := &Analyzer{
+		rules:    make([]Rule, len(rules)),
+		patterns: make([]*regexp.Regexp, len(rules)),
+	}
+	for i, rule := range rules {
+		if len(rule.Name) == 0 {
+			rule.Name = fmt.Sprintf("rules[%d]", i)
+		}
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, &InvalidRuleError{Index: i, Rule: rule, Err: err}
+		}
+		a.rules[i] = rule
+		a.patterns[i] = re
+	}
+	return a, nil
+}
+
+// Lint checks that message matches a rule
+func (analyzer *Analyzer) Lint(message string) []error {
+	if analyzer.match(message) == nil {
+		return []error{errors.New("message matches no rule")}
+	}
+	return []error{}
+}
+
+// Analyze implements semrel.Analyzer interface for rulecommit.Analyzer.
+// Commits that match no rule are ignored.
+func (analyzer *Analyzer) Analyze(commit *semrel.Commit) ([]semrel.Change, error) {
+	changes := []semrel.Change{}
+	c := analyzer.match(commit.Msg)
+	if c == nil {
+		return changes, nil
+	}
+	semrel.Logf("%.7s: matched rule '%s'", commit.SHA, c.Rule.Name)
+	c.Hash = commit.SHA
+	c.commit = *commit
+	return append(changes, c), nil
+}
+
+// match returns the change of the first matching rule, or nil
+func (analyzer *Analyzer) match(message string) *Change {
+	text := strings.TrimSpace(strings.Replace(message, "\r", "", -1))
+	for i, re := range analyzer.patterns {
+		match := re.FindStringSubmatch(text)
+		if match == nil {
+			continue
+		}
+		c := &Change{Rule: analyzer.rules[i]}
+		for j, name := range re.SubexpNames() {
+			value := strings.TrimSpace(match[j])
+			switch name {
+			case "type":
+				c.Type = value
+			case "scope":
+				c.Scope = value
+			case "subject":
+				c.Subject = value
+			}
+		}
+		if len(c.Subject) == 0 {
+			c.Subject = strings.SplitN(text, "\n", 2)[0]
+		}
+		return c
+	}
+	return nil
+}
+
+// Change captures commit message analysis
+type Change struct {
+	// Rule that matched the message
+	Rule    Rule
+	Type    string
+	Scope   string
+	Subject string
+	Hash    string
+	commit  semrel.Commit
+}
+
+// Category implements semrel.Change interface
+func (c *Change) Category() string {
+	if len(c.Rule.Category) > 0 {
+		return c.Rule.Category
+	}
+	return semrel.DefaultCategory(c.Rule.BumpLevel)
+}
+
+// BumpLevel implements semrel.Change interface
+func (c *Change) BumpLevel() semrel.BumpLevel {
+	return c.Rule.BumpLevel
+}
+
+// PreReleased implements semrel.Change interface
+func (c *Change) PreReleased() bool {
+	return c.commit.PreReleased
+}
+
+// Describe implements semrel.Describer interface
+func (c *Change) Describe() semrel.Description {
+	return semrel.Description{
+		Scope:   c.Scope,
+		Subject: c.Subject,
+		SHA:     c.Hash,
+	}
+}
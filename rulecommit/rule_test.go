@@ -0,0 +1,65 @@
+package rulecommit
+
+import (
+	"testing"
+
+	"github.com/juranki/go-semrel/semrel"
+)
+
+var rules = []Rule{
+	{Name: "breaking", Pattern: `(?m)^BREAKING:`, BumpLevel: semrel.BumpMajor},
+	{Name: "ticket", Pattern: `^\[(?P<scope>[A-Z]+-\d+)\]\s*(?P<type>Add|Fix)\s+(?P<subject>.*)`, BumpLevel: semrel.BumpPatch, Category: "ticket"},
+	{Pattern: `^feature:\s*(?P<subject>.*)`, BumpLevel: semrel.BumpMinor},
+}
+
+func TestAnalyzer(t *testing.T) {
+	a, err := New(rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		msg      string
+		rule     string
+		category string
+		scope    string
+		subject  string
+	}{
+		{"[PROJ-1] Fix crash on start", "ticket", "ticket", "PROJ-1", "crash on start"},
+		{"feature: export\n\nbody", "rules[2]", "feature", "", "export"},
+		{"[PROJ-2] Add x\n\nBREAKING: y", "breaking", "breaking", "", "[PROJ-2] Add x"},
+		{"misc cleanup", "", "", "", ""},
+	}
+	for _, c := range cases {
+		changes, err := a.Analyze(&semrel.Commit{Msg: c.msg, SHA: "abc"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(c.rule) == 0 {
+			if len(changes) != 0 {
+				t.Errorf("'%s': got %+v, want no changes", c.msg, changes)
+			}
+			if errs := a.Lint(c.msg); len(errs) != 1 {
+				t.Errorf("'%s': got lint %v", c.msg, errs)
+			}
+			continue
+		}
+		if len(changes) != 1 {
+			t.Fatalf("'%s': got %d changes", c.msg, len(changes))
+		}
+		change := changes[0].(*Change)
+		if change.Rule.Name != c.rule || change.Category() != c.category || change.Scope != c.scope || change.Subject != c.subject {
+			t.Errorf("'%s': got %s/%s/%s/%s", c.msg, change.Rule.Name, change.Category(), change.Scope, change.Subject)
+		}
+		if errs := a.Lint(c.msg); len(errs) != 0 {
+			t.Errorf("'%s': got lint %v", c.msg, errs)
+		}
+	}
+}
+
+func TestNew_InvalidRule(t *testing.T) {
+	_, err := New([]Rule{{Pattern: "ok"}, {Name: "broken", Pattern: "("}})
+	e, ok := err.(*InvalidRuleError)
+	if !ok || e.Index != 1 || e.Rule.Name != "broken" {
+		t.Errorf("got %v", err)
+	}
+}
@@ -0,0 +1,27 @@
+package semrel
+
+// Logger receives diagnostic messages from go-semrel packages.
+// *log.Logger implements the interface.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Printf(format string, v ...interface{}) {}
+
+var logger Logger = nopLogger{}
+
+// SetLogger sets the logger for diagnostic messages. Messages are
+// discarded by default, or when logger is nil.
+func SetLogger(l Logger) {
+	if l == nil {
+		l = nopLogger{}
+	}
+	logger = l
+}
+
+// Logf writes a diagnostic message to the logger set with SetLogger
+func Logf(format string, v ...interface{}) {
+	logger.Printf(format, v...)
+}
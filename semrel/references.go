@@ -0,0 +1,69 @@
+package semrel
+
+import (
+	"regexp"
+	"sort"
+)
+
+// Reference to an issue, merge request or ticket mentioned in a commit
+type Reference struct {
+	// ID as written in the message, e.g. #123 or PROJ-123
+	ID  string
+	URL string
+}
+
+// ReferencePattern finds references in commit messages
+type ReferencePattern struct {
+	// Regexp matches the ID of the reference
+	Regexp *regexp.Regexp
+	// URL template, submatches are expanded as in regexp.Expand,
+	// e.g. https://github.com/owner/repo/issues/$1
+	URL string
+}
+
+// NewReferencePattern compiles pattern into ReferencePattern
+func NewReferencePattern(pattern, url string) (ReferencePattern, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return ReferencePattern{}, err
+	}
+	return ReferencePattern{Regexp: re, URL: url}, nil
+}
+
+// FindReferences returns references in text in order of appearance, each ID
+// once. Where matches of patterns overlap, the earlier pattern wins.
+func FindReferences(text string, patterns []ReferencePattern) []Reference {
+	type match struct {
+		start, end int
+		ref        Reference
+	}
+	matches := []match{}
+	for _, p := range patterns {
+	next:
+		for _, loc := range p.Regexp.FindAllStringSubmatchIndex(text, -1) {
+			if loc[0] == loc[1] {
+				continue
+			}
+			for _, m := range matches {
+				if loc[0] < m.end && m.start < loc[1] {
+					continue next
+				}
+			}
+			url := p.Regexp.ExpandString(nil, p.URL, text, loc)
+			matches = append(matches, match{loc[0], loc[1], Reference{
+				ID:  text[loc[0]:loc[1]],
+				URL: string(url),
+			}})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].start < matches[j].start })
+	refs := []Reference{}
+	seen := map[string]bool{}
+	for _, m := range matches {
+		if !seen[m.ref.ID] {
+			seen[m.ref.ID] = true
+			refs = append(refs, m.ref)
+		}
+	}
+	return refs
+}
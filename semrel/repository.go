@@ -0,0 +1,96 @@
+package semrel
+
+import (
+	"strings"
+)
+
+// Flavor of a repository hosting service
+type Flavor string
+
+// Supported hosting services
+const (
+	UnknownFlavor Flavor = ""
+	GitHub        Flavor = "github"
+	GitLab        Flavor = "gitlab"
+	Bitbucket     Flavor = "bitbucket"
+	Gitea         Flavor = "gitea"
+)
+
+// RepositoryInfo describes where the repository is hosted. URL templates
+// contain placeholders {sha}, {from}, {to} and {tag}, they are empty when
+// the flavor is unknown.
+type RepositoryInfo struct {
+	Flavor Flavor
+	// WebURL of the repository, e.g. https://github.com/owner/repo
+	WebURL string
+	// CommitURL template, e.g. https://github.com/owner/repo/commit/{sha}
+	CommitURL string
+	// CompareURL template, e.g. https://github.com/owner/repo/compare/{from}...{to}
+	CompareURL string
+	// TagURL template, e.g. https://github.com/owner/repo/releases/tag/{tag}
+	TagURL string
+}
+
+var urlTemplates = map[Flavor][3]string{
+	GitHub:    {"/commit/{sha}", "/compare/{from}...{to}", "/releases/tag/{tag}"},
+	GitLab:    {"/-/commit/{sha}", "/-/compare/{from}...{to}", "/-/tags/{tag}"},
+	Bitbucket: {"/commits/{sha}", "/branches/compare/{to}%0D{from}", "/src/{tag}"},
+	Gitea:     {"/commit/{sha}", "/compare/{from}...{to}", "/src/tag/{tag}"},
+}
+
+// NewRepositoryInfo returns RepositoryInfo with URL templates of flavor.
+// Flavor is detected from the host name of webURL when it's UnknownFlavor.
+func NewRepositoryInfo(webURL string, flavor Flavor) *RepositoryInfo {
+	webURL = strings.TrimSuffix(webURL, "/")
+	if flavor == UnknownFlavor {
+		flavor = detectFlavor(webURL)
+	}
+	info := &RepositoryInfo{Flavor: flavor, WebURL: webURL}
+	if templates, ok := urlTemplates[flavor]; ok {
+		info.CommitURL = webURL + templates[0]
+		info.CompareURL = webURL + templates[1]
+		info.TagURL = webURL + templates[2]
+	}
+	return info
+}
+
+func detectFlavor(webURL string) Flavor {
+	host := strings.TrimPrefix(strings.TrimPrefix(webURL, "https://"), "http://")
+	if i := strings.IndexAny(host, "/:"); i >= 0 {
+		host = host[:i]
+	}
+	host = strings.ToLower(host)
+	switch {
+	case strings.Contains(host, "github"):
+		return GitHub
+	case strings.Contains(host, "gitlab"):
+		return GitLab
+	case strings.Contains(host, "bitbucket"):
+		return Bitbucket
+	case strings.Contains(host, "gitea"), strings.Contains(host, "forgejo"), host == "codeberg.org":
+		return Gitea
+	}
+	return UnknownFlavor
+}
+
+// Commit returns the URL of commit sha, or empty string
+func (info *RepositoryInfo) Commit(sha string) string {
+	return expand(info.CommitURL, "{sha}", sha)
+}
+
+// Compare returns the URL of changes between refs from and to, or empty string
+func (info *RepositoryInfo) Compare(from, to string) string {
+	return expand(info.CompareURL, "{from}", from, "{to}", to)
+}
+
+// Tag returns the URL of tag, or empty string
+func (info *RepositoryInfo) Tag(tag string) string {
+	return expand(info.TagURL, "{tag}", tag)
+}
+
+func expand(template string, placeholders ...string) string {
+	if len(template) == 0 {
+		return ""
+	}
+	return strings.NewReplacer(placeholders...).Replace(template)
+}
@@ -20,6 +20,20 @@ const (
 	BumpMajor           = iota
 )
 
+var defaultCategories = map[BumpLevel]string{
+	NoBump:    "other",
+	BumpMajor: "breaking",
+	BumpMinor: "feature",
+	BumpPatch: "fix",
+}
+
+// DefaultCategory returns the category of changes of level, as used by
+// the default sections of release notes: "breaking", "feature", "fix"
+// or "other"
+func DefaultCategory(level BumpLevel) string {
+	return defaultCategories[level]
+}
+
 // ChangeAnalyzer analyzes a commit message and returns 0 or more entries to release note
 type ChangeAnalyzer interface {
 	Analyze(commit *Commit) ([]Change, error)
@@ -31,6 +45,28 @@ type VCSData struct {
 	UnreleasedCommits []Commit
 	// Time of the commit being released
 	Time time.Time
+	// SHA of the commit being released, empty if not known
+	SHA string
+	// Repository is nil when the location of the repository is not known
+	Repository *RepositoryInfo
+	// PreviousRelease is the tag of CurrentVersion, nil if there is none
+	PreviousRelease *Tag
+}
+
+// Tag of a release
+type Tag struct {
+	// Name of the tag, including prefix
+	Name    string
+	Version semver.Version
+	// SHA of the tagged commit
+	SHA       string
+	Annotated bool
+	// Tagger of annotated tag, "Name <email>"
+	Tagger string
+	// Date of annotated tag, or the time of the tagged commit
+	Date time.Time
+	// Message of annotated tag
+	Message string
 }
 
 // Commit contains VCS commit data
@@ -40,6 +76,9 @@ type Commit struct {
 	Time        time.Time
 	PreReleased bool
 	IsMerge     bool
+	// IsFirstParent is true when the commit is reachable from the released
+	// commit by following only first parents
+	IsFirstParent bool
 }
 
 // ByTime implements sort.Interface for []Commit based on Time().
@@ -56,6 +95,35 @@ type Change interface {
 	PreReleased() bool
 }
 
+// Describer is an optional interface of Change that provides details
+// for release notes
+type Describer interface {
+	Describe() Description
+}
+
+// Description of a change for release notes
+type Description struct {
+	Scope   string
+	Subject string
+	// Breaking describes the breaking change, if any
+	Breaking   string
+	SHA        string
+	References []Reference
+}
+
+// VersionOverrider is an optional interface of Change that sets the next
+// version explicitly, instead of bumping the current version
+type VersionOverrider interface {
+	// OverrideVersion returns nil when the change doesn't override version
+	OverrideVersion() *semver.Version
+}
+
+// Override records the change that set the next version explicitly
+type Override struct {
+	Version semver.Version
+	Change  Change
+}
+
 // ReleaseData contains information for next release
 type ReleaseData struct {
 	CurrentVersion semver.Version
@@ -64,6 +132,10 @@ type ReleaseData struct {
 	Changes        map[string][]Change
 	// Time of the commit being released
 	Time time.Time
+	// Repository is copied from VCSData
+	Repository *RepositoryInfo
+	// Override is set when a change overrides the next version
+	Override *Override
 }
 
 // Release processes the release data
@@ -74,6 +146,7 @@ func Release(input *VCSData, analyzer ChangeAnalyzer) (*ReleaseData, error) {
 		BumpLevel:      NoBump,
 		Changes:        map[string][]Change{},
 		Time:           input.Time,
+		Repository:     input.Repository,
 	}
 	for _, commit := range input.UnreleasedCommits {
 		changes, err := analyzer.Analyze(&commit)
@@ -89,12 +162,42 @@ func Release(input *VCSData, analyzer ChangeAnalyzer) (*ReleaseData, error) {
 			if change.BumpLevel() > output.BumpLevel {
 				output.BumpLevel = change.BumpLevel()
 			}
+			if o, ok := change.(VersionOverrider); ok {
+				v := o.OverrideVersion()
+				if v != nil && (output.Override == nil || v.GT(output.Override.Version)) {
+					output.Override = &Override{Version: *v, Change: change}
+				}
+			}
 		}
 	}
+	if output.Override != nil {
+		v := output.Override.Version
+		if !v.GT(output.CurrentVersion) {
+			return nil, fmt.Errorf("override version %s is not greater than current version %s", v, output.CurrentVersion)
+		}
+		output.NextVersion = v
+		output.BumpLevel = bumpLevel(output.CurrentVersion, v)
+		Logf("%d unreleased commits, override %s with %s", len(input.UnreleasedCommits), output.CurrentVersion, output.NextVersion)
+		return output, nil
+	}
 	output.NextVersion = bump(output.CurrentVersion, output.BumpLevel)
+	Logf("%d unreleased commits, bump %s to %s", len(input.UnreleasedCommits), output.CurrentVersion, output.NextVersion)
 	return output, nil
 }
 
+// bumpLevel returns the level of bump from curr to next
+func bumpLevel(curr, next semver.Version) BumpLevel {
+	switch {
+	case next.Major > curr.Major:
+		return BumpMajor
+	case next.Minor > curr.Minor:
+		return BumpMinor
+	case next.GT(curr):
+		return BumpPatch
+	}
+	return NoBump
+}
+
 func bump(curr semver.Version, bumpLevel BumpLevel) semver.Version {
 	var major uint64
 	var minor uint64
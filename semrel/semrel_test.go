@@ -2,6 +2,7 @@ package semrel
 
 import (
 	"fmt"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -39,7 +40,7 @@ func TestNoChangesBump(t *testing.T) {
 	input := &VCSData{
 		CurrentVersion: semver.MustParse("0.1.0"),
 		UnreleasedCommits: []Commit{
-			{"aaa", "", time.Now(), false, false},
+			{Msg: "aaa", Time: time.Now()},
 		},
 	}
 	output, err := Release(input, dummyAnalyzer)
@@ -76,7 +77,7 @@ func TestRelease1(t *testing.T) {
 	input := &VCSData{
 		CurrentVersion: semver.MustParse("0.0.0"),
 		UnreleasedCommits: []Commit{
-			{"fix", "", time.Now(), false, false},
+			{Msg: "fix", Time: time.Now()},
 		},
 	}
 	output, err := Release(input, dummyAnalyzer)
@@ -95,10 +96,10 @@ func TestRelease2(t *testing.T) {
 	input := &VCSData{
 		CurrentVersion: semver.MustParse("1.2.3"),
 		UnreleasedCommits: []Commit{
-			{"fix", "", time.Now(), false, false},
-			{"fix", "", time.Now(), false, false},
-			{"feat", "", time.Now(), false, false},
-			{"break", "", time.Now(), false, false},
+			{Msg: "fix", Time: time.Now()},
+			{Msg: "fix", Time: time.Now()},
+			{Msg: "feat", Time: time.Now()},
+			{Msg: "break", Time: time.Now()},
 		},
 	}
 	output, err := Release(input, dummyAnalyzer)
@@ -123,10 +124,10 @@ func TestRelease3(t *testing.T) {
 	input := &VCSData{
 		CurrentVersion: semver.MustParse("1.2.3"),
 		UnreleasedCommits: []Commit{
-			{"fix", "", time.Now(), false, false},
-			{"fix", "", time.Now(), false, false},
-			{"fail", "", time.Now(), false, false},
-			{"break", "", time.Now(), false, false},
+			{Msg: "fix", Time: time.Now()},
+			{Msg: "fix", Time: time.Now()},
+			{Msg: "fail", Time: time.Now()},
+			{Msg: "break", Time: time.Now()},
 		},
 	}
 	_, err := Release(input, dummyAnalyzer)
@@ -134,3 +135,103 @@ func TestRelease3(t *testing.T) {
 		t.Errorf("got %+v, want error", err)
 	}
 }
+
+type testLogger []string
+
+func (l *testLogger) Printf(format string, v ...interface{}) {
+	*l = append(*l, fmt.Sprintf(format, v...))
+}
+
+func TestSetLogger(t *testing.T) {
+	logger := &testLogger{}
+	SetLogger(logger)
+	defer SetLogger(nil)
+	input := &VCSData{
+		CurrentVersion: semver.MustParse("1.2.3"),
+		UnreleasedCommits: []Commit{
+			{Msg: "fix", Time: time.Now()},
+		},
+	}
+	if _, err := Release(input, dummyAnalyzer); err != nil {
+		t.Fatal(err)
+	}
+	want := "1 unreleased commits, bump 1.2.3 to 1.2.4"
+	if len(*logger) != 1 || (*logger)[0] != want {
+		t.Errorf("got %v, want [%s]", *logger, want)
+	}
+	SetLogger(nil)
+	Logf("discarded")
+	if len(*logger) != 1 {
+		t.Errorf("got %v after SetLogger(nil)", *logger)
+	}
+}
+
+func TestFindReferences(t *testing.T) {
+	patterns := []ReferencePattern{}
+	for _, p := range [][2]string{
+		{`#(\d+)`, "https://github.com/o/r/issues/$1"},
+		{`GH-(\d+)`, "https://github.com/o/r/issues/$1"},
+		{`!(\d+)`, "https://gitlab.com/g/p/-/merge_requests/$1"},
+		{`\b(?P<key>[A-Z][A-Z0-9]+-\d+)\b`, "https://jira.example.com/browse/${key}"},
+	} {
+		pattern, err := NewReferencePattern(p[0], p[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		patterns = append(patterns, pattern)
+	}
+	msg := "fix: crash in PROJ-123 (#12)\n\nsee GH-45 and !67\n\nCloses: #12, #13"
+	want := []Reference{
+		{"PROJ-123", "https://jira.example.com/browse/PROJ-123"},
+		{"#12", "https://github.com/o/r/issues/12"},
+		{"GH-45", "https://github.com/o/r/issues/45"},
+		{"!67", "https://gitlab.com/g/p/-/merge_requests/67"},
+		{"#13", "https://github.com/o/r/issues/13"},
+	}
+	if got := FindReferences(msg, patterns); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	if _, err := NewReferencePattern("(", ""); err == nil {
+		t.Error("want error for invalid pattern")
+	}
+}
+
+func TestRepositoryInfo(t *testing.T) {
+	tests := []struct {
+		webURL  string
+		flavor  Flavor
+		want    Flavor
+		commit  string
+		compare string
+		tag     string
+	}{
+		{"https://github.com/o/r", UnknownFlavor, GitHub,
+			"https://github.com/o/r/commit/abc", "https://github.com/o/r/compare/v1.0.0...v1.1.0", "https://github.com/o/r/releases/tag/v1.1.0"},
+		{"https://gitlab.example.com/g/p/", UnknownFlavor, GitLab,
+			"https://gitlab.example.com/g/p/-/commit/abc", "https://gitlab.example.com/g/p/-/compare/v1.0.0...v1.1.0", "https://gitlab.example.com/g/p/-/tags/v1.1.0"},
+		{"https://bitbucket.org/t/r", UnknownFlavor, Bitbucket,
+			"https://bitbucket.org/t/r/commits/abc", "https://bitbucket.org/t/r/branches/compare/v1.1.0%0Dv1.0.0", "https://bitbucket.org/t/r/src/v1.1.0"},
+		{"https://git.example.com/o/r", Gitea, Gitea,
+			"https://git.example.com/o/r/commit/abc", "https://git.example.com/o/r/compare/v1.0.0...v1.1.0", "https://git.example.com/o/r/src/tag/v1.1.0"},
+		{"https://git.example.com/o/r", UnknownFlavor, UnknownFlavor, "", "", ""},
+	}
+	for _, tt := range tests {
+		info := NewRepositoryInfo(tt.webURL, tt.flavor)
+		if info.Flavor != tt.want {
+			t.Errorf("%s: got flavor '%s', want '%s'", tt.webURL, info.Flavor, tt.want)
+		}
+		got := [3]string{info.Commit("abc"), info.Compare("v1.0.0", "v1.1.0"), info.Tag("v1.1.0")}
+		if got != [3]string{tt.commit, tt.compare, tt.tag} {
+			t.Errorf("%s: got %v", tt.webURL, got)
+		}
+	}
+}
+
+func TestDefaultCategory(t *testing.T) {
+	want := map[BumpLevel]string{NoBump: "other", BumpPatch: "fix", BumpMinor: "feature", BumpMajor: "breaking"}
+	for level, category := range want {
+		if got := DefaultCategory(level); got != category {
+			t.Errorf("%d: got %s, want %s", level, got, category)
+		}
+	}
+}
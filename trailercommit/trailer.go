@@ -0,0 +1,190 @@
+// Package trailercommit overrides the analysis of commits with git trailers
+//
+// Trailers are `Key: value` lines in the last paragraph of the message:
+//
+//	fix: rename option
+//
+//	Semver: major
+//
+// `Semver: major|minor|patch|none` sets the bump level of the commit,
+// `Release-As: 2.0.0` sets the next version explicitly, see
+// semrel.VersionOverrider.
+package trailercommit
+
+import (
+	"strings"
+
+	"github.com/blang/semver"
+	"github.com/juranki/go-semrel/semrel"
+	"github.com/pkg/errors"
+)
+
+var (
+	semverLevels = map[string]semrel.BumpLevel{
+		"major": semrel.BumpMajor,
+		"minor": semrel.BumpMinor,
+		"patch": semrel.BumpPatch,
+		"none":  semrel.NoBump,
+	}
+)
+
+// Analyzer wraps another analyzer and applies trailer overrides to
+// the changes it returns
+type Analyzer struct {
+	analyzer semrel.ChangeAnalyzer
+}
+
+// New initializes Analyzer on top of analyzer
+func New(analyzer semrel.ChangeAnalyzer) *Analyzer {
+	return &Analyzer{
+		analyzer: analyzer,
+	}
+}
+
+// Lint checks the values of Semver and Release-As trailers
+func (analyzer *Analyzer) Lint(message string) []error {
+	errs := []error{}
+	if _, err := parseOverride(message); err != nil {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// Analyze implements semrel.Analyzer interface. When the commit has
+// overriding trailers and the wrapped analyzer finds no changes, a change
+// is returned for the head line of the message.
+func (analyzer *Analyzer) Analyze(commit *semrel.Commit) ([]semrel.Change, error) {
+	changes, err := analyzer.analyzer.Analyze(commit)
+	if err != nil {
+		return nil, err
+	}
+	o, err := parseOverride(commit.Msg)
+	if err != nil {
+		return nil, errors.Wrapf(err, "commit %s", commit.SHA)
+	}
+	if o == nil {
+		return changes, nil
+	}
+	if len(changes) == 0 {
+		changes = append(changes, nil)
+	}
+	result := make([]semrel.Change, len(changes))
+	for i, change := range changes {
+		c := &Change{
+			Change:   change,
+			override: *o,
+			commit:   *commit,
+		}
+		if !o.hasLevel {
+			if change != nil {
+				c.override.level = change.BumpLevel()
+			} else {
+				c.override.level = semrel.NoBump
+			}
+		}
+		result[i] = c
+	}
+	return result, nil
+}
+
+// Change is a change with overridden bump level or version
+type Change struct {
+	// Change of the wrapped analyzer, nil if there was none
+	Change   semrel.Change
+	override override
+	commit   semrel.Commit
+}
+
+type override struct {
+	level    semrel.BumpLevel
+	hasLevel bool
+	version  *semver.Version
+}
+
+// Category implements semrel.Change interface. The category of
+// the wrapped change is kept unless the bump level is overridden.
+func (c *Change) Category() string {
+	if c.Change != nil && !c.override.hasLevel {
+		return c.Change.Category()
+	}
+	return semrel.DefaultCategory(c.override.level)
+}
+
+// BumpLevel implements semrel.Change interface
+func (c *Change) BumpLevel() semrel.BumpLevel {
+	return c.override.level
+}
+
+// PreReleased implements semrel.Change interface
+func (c *Change) PreReleased() bool {
+	return c.commit.PreReleased
+}
+
+// OverrideVersion implements semrel.VersionOverrider interface
+func (c *Change) OverrideVersion() *semver.Version {
+	return c.override.version
+}
+
+// Describe implements semrel.Describer interface
+func (c *Change) Describe() semrel.Description {
+	if d, ok := c.Change.(semrel.Describer); ok {
+		return d.Describe()
+	}
+	return semrel.Description{
+		Subject: strings.TrimSpace(strings.SplitN(c.commit.Msg, "\n", 2)[0]),
+		SHA:     c.commit.SHA,
+	}
+}
+
+// parseOverride returns the override in the trailers of message,
+// or nil if there is none
+func parseOverride(message string) (*override, error) {
+	var o *override
+	for _, trailer := range trailers(message) {
+		switch strings.ToLower(trailer[0]) {
+		case "semver":
+			level, ok := semverLevels[strings.ToLower(trailer[1])]
+			if !ok {
+				return nil, errors.Errorf("invalid Semver trailer '%s', want major, minor, patch or none", trailer[1])
+			}
+			if o == nil {
+				o = &override{}
+			}
+			o.level = level
+			o.hasLevel = true
+		case "release-as":
+			v, err := semver.ParseTolerant(trailer[1])
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid Release-As trailer '%s'", trailer[1])
+			}
+			if o == nil {
+				o = &override{}
+			}
+			o.version = &v
+		}
+	}
+	return o, nil
+}
+
+// trailers returns key-value pairs of the last paragraph of message.
+// Lines that are not trailers, e.g. BREAKING CHANGE, are skipped.
+func trailers(message string) [][2]string {
+	t := strings.TrimSpace(strings.Replace(message, "\r", "", -1))
+	paragraphs := strings.Split(t, "\n\n")
+	if len(paragraphs) < 2 {
+		return nil
+	}
+	var result [][2]string
+	for _, line := range strings.Split(paragraphs[len(paragraphs)-1], "\n") {
+		if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+			// continuation of previous value
+			continue
+		}
+		i := strings.Index(line, ":")
+		if i <= 0 || strings.ContainsAny(line[:i], " \t") {
+			continue
+		}
+		result = append(result, [2]string{line[:i], strings.TrimSpace(line[i+1:])})
+	}
+	return result
+}
@@ -0,0 +1,84 @@
+package trailercommit
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/blang/semver"
+	"github.com/juranki/go-semrel/angularcommit"
+	"github.com/juranki/go-semrel/semrel"
+)
+
+func TestTrailers(t *testing.T) {
+	cases := []struct {
+		msg  string
+		want [][2]string
+	}{
+		{"fix: x\n\nSemver: major", [][2]string{{"Semver", "major"}}},
+		{"fix: x\n\nbody\n\nRelease-As: 2.0.0\nSigned-off-by: A <a@b>\n  continued", [][2]string{{"Release-As", "2.0.0"}, {"Signed-off-by", "A <a@b>"}}},
+		{"fix: x\n\nnot a trailer: here", nil},
+		{"fix: x\n\nBREAKING CHANGE: y\nSemver: minor", [][2]string{{"Semver", "minor"}}},
+		{"Semver: major", nil},
+	}
+	for _, c := range cases {
+		if got := trailers(c.msg); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("'%s': got %v, want %v", c.msg, got, c.want)
+		}
+	}
+}
+
+func release(t *testing.T, current string, msgs ...string) (*semrel.ReleaseData, error) {
+	t.Helper()
+	data := &semrel.VCSData{CurrentVersion: semver.MustParse(current)}
+	for _, msg := range msgs {
+		data.UnreleasedCommits = append(data.UnreleasedCommits, semrel.Commit{Msg: msg, SHA: "abc"})
+	}
+	return semrel.Release(data, New(angularcommit.New()))
+}
+
+func TestAnalyzer(t *testing.T) {
+	cases := []struct {
+		msgs []string
+		want string
+	}{
+		{[]string{"fix: x"}, "1.2.4"},
+		{[]string{"fix: x\n\nSemver: major"}, "2.0.0"},
+		{[]string{"feat: x\n\nSemver: none", "fix: y"}, "1.2.4"},
+		{[]string{"update readme\n\nSemver: minor"}, "1.3.0"},
+		{[]string{"chore: release\n\nRelease-As: v3.0.0", "feat: x\n\nRelease-As: 2.0.0"}, "3.0.0"},
+		{[]string{"fix: x\n\nRelease-As: 1.2.10"}, "1.2.10"},
+	}
+	for _, c := range cases {
+		r, err := release(t, "1.2.3", c.msgs...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.NextVersion.String() != c.want {
+			t.Errorf("%q: got %s, want %s", c.msgs, r.NextVersion, c.want)
+		}
+	}
+
+	r, _ := release(t, "1.2.3", "feat: x\n\nRelease-As: 2.0.0")
+	if r.Override == nil || r.Override.Version.String() != "2.0.0" || r.BumpLevel != semrel.BumpMajor {
+		t.Errorf("got %+v", r)
+	}
+	if d := r.Override.Change.(semrel.Describer).Describe(); d.Subject != "x" {
+		t.Errorf("got %+v", d)
+	}
+	if _, err := release(t, "1.2.3", "fix: x\n\nRelease-As: 1.2.3"); err == nil {
+		t.Error("want error when override is not greater than current version")
+	}
+	if _, err := release(t, "1.2.3", "fix: x\n\nSemver: huge"); err == nil {
+		t.Error("want error for invalid trailer")
+	}
+}
+
+func TestLint(t *testing.T) {
+	a := New(angularcommit.New())
+	if errs := a.Lint("fix: x\n\nRelease-As: 1.0"); len(errs) != 0 {
+		t.Errorf("got %v", errs)
+	}
+	if errs := a.Lint("fix: x\n\nRelease-As: next"); len(errs) != 1 {
+		t.Errorf("got %v", errs)
+	}
+}